@@ -0,0 +1,84 @@
+package errorid
+
+import "fmt"
+
+// MaxAttachmentSize is the largest attachment data kept; longer data is
+// cut and the attachment marked Truncated. Attachments are meant for small
+// artifacts: the failing payload, a diff, a screenshot path
+const MaxAttachmentSize = 256 << 10
+
+// Attachment is a small artifact carried by an error to capable sinks
+// (DiscordNotifier uploads them as files). Loggers only see its summary
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+	Truncated   bool // Data was cut to MaxAttachmentSize
+}
+
+// String summarizes a without its data, e.g. "payload.json (application/json, 512 B)"
+func (a Attachment) String() string {
+	if a.Truncated {
+		return fmt.Sprintf("%s (%s, %d B, truncated)", a.Name, a.ContentType, len(a.Data))
+	}
+	return fmt.Sprintf("%s (%s, %d B)", a.Name, a.ContentType, len(a.Data))
+}
+
+// attachedError carries an Attachment through the error chain
+type attachedError struct {
+	error
+	attachment Attachment
+}
+
+// Unwrap returns the error the attachment was added to
+func (e *attachedError) Unwrap() error {
+	return e.error
+}
+
+// WithAttachment returns err carrying an attachment. Wrapping the result
+// puts it in ErrorWithID.Attachments; add attachments before wrapping,
+// since sinks receive the error during Wrap:
+//
+//	h.Wrap(errorid.WithAttachment(err, "order.json", "application/json", body), "decode order")
+//
+// data is copied. Returns nil if err is nil
+func WithAttachment(err error, name, contentType string, data []byte) error {
+	if err == nil {
+		return nil
+	}
+	
+	attachment := Attachment{Name: name, ContentType: contentType}
+	if len(data) > MaxAttachmentSize {
+		data = data[:MaxAttachmentSize]
+		attachment.Truncated = true
+	}
+	attachment.Data = append([]byte(nil), data...)
+	
+	return &attachedError{error: err, attachment: attachment}
+}
+
+// attachmentsOf collects the attachments in err's chain, outermost first
+// Wrapped errors contribute their Attachments without being descended into
+func attachmentsOf(err error) []Attachment {
+	var attachments []Attachment
+	for stack := []error{err}; len(stack) > 0; {
+		err, stack = stack[0], stack[1:]
+		switch e := err.(type) {
+		case nil:
+			continue
+		case *ErrorWithID:
+			attachments = append(attachments, e.Attachments...)
+			continue
+		case *attachedError:
+			attachments = append(attachments, e.attachment)
+		}
+		
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			stack = append([]error{e.Unwrap()}, stack...)
+		case interface{ Unwrap() []error }:
+			stack = append(e.Unwrap(), stack...)
+		}
+	}
+	return attachments
+}
@@ -0,0 +1,221 @@
+package errorid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Coder is implemented by errors that carry a machine-readable code
+type Coder interface {
+	ErrorCode() string
+}
+
+// ErrorCode returns the code of the first error in err's chain that
+// implements Coder, or "" if there is none
+func ErrorCode(err error) string {
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	return ""
+}
+
+// BatchItemError is the per-item entry of a BatchErrorResponse
+type BatchItemError struct {
+	Key     string `json:"key"`
+	ErrorID string `json:"error_id"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// BatchSummary aggregates the outcome of a bulk operation
+// ByCode groups failures by ErrorCode ("unknown" when an error has none)
+type BatchSummary struct {
+	Total     int            `json:"total"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	ByCode    map[string]int `json:"by_code,omitempty"`
+}
+
+// BatchErrorResponse is the JSON envelope for partially failed bulk operations
+// The top-level error_id identifies the batch as a whole, so clients that
+// only understand ErrorResponse still get one support ID
+type BatchErrorResponse struct {
+	ErrorID   string           `json:"error_id"`
+	Message   string           `json:"message"`
+	Timestamp int64            `json:"timestamp"`
+	Summary   BatchSummary     `json:"summary"`
+	Errors    []BatchItemError `json:"errors"`
+}
+
+// Batch collects per-item results of a bulk operation (e.g., a 100-item import)
+// Each failed item is wrapped with its own ID; the batch gets one more ID
+// for the summary. Safe for concurrent use
+type Batch struct {
+	handler *Handler
+	context string
+	
+	mu       sync.Mutex
+	total    int
+	failures []*batchFailure
+	summary  *ErrorWithID // summary error, created by Response, reset by Add
+}
+
+// batchFailure is one failed item of a Batch
+type batchFailure struct {
+	key string
+	err *ErrorWithID
+}
+
+// NewBatch starts a batch using the default handler
+func NewBatch(context string) *Batch {
+	return defaultFor(2).NewBatch(context)
+}
+
+// NewBatch starts a batch using this handler instance
+// context is used for every item error and for the summary error
+func (h *Handler) NewBatch(context string) *Batch {
+	return &Batch{
+		handler: h,
+		context: context,
+	}
+}
+
+// Add records the outcome of one item. A nil err counts as success
+// Non-nil errors are wrapped with an ID (detail "batch_key" = key) and returned
+// Adding after Response makes the next Response wrap a new summary error
+func (b *Batch) Add(key string, err error) *ErrorWithID {
+	b.mu.Lock()
+	b.total++
+	b.summary = nil
+	b.mu.Unlock()
+	
+	if err == nil {
+		return nil
+	}
+	
+	wrapped := b.handler.WrapWithDetails(err, b.context, map[string]interface{}{
+		"batch_key": key,
+	})
+	
+	b.mu.Lock()
+	b.failures = append(b.failures, &batchFailure{key: key, err: wrapped})
+	b.mu.Unlock()
+	
+	return wrapped
+}
+
+// Failed returns the number of failed items so far
+func (b *Batch) Failed() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.failures)
+}
+
+// Summary returns the aggregated counts so far
+func (b *Batch) Summary() BatchSummary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.summaryLocked()
+}
+
+// summaryLocked computes the summary, b.mu must be held
+func (b *Batch) summaryLocked() BatchSummary {
+	summary := BatchSummary{
+		Total:     b.total,
+		Succeeded: b.total - len(b.failures),
+		Failed:    len(b.failures),
+	}
+	
+	if len(b.failures) > 0 {
+		summary.ByCode = make(map[string]int)
+		for _, failure := range b.failures {
+			code := ErrorCode(failure.err)
+			if code == "" {
+				code = "unknown"
+			}
+			summary.ByCode[code]++
+		}
+	}
+	
+	return summary
+}
+
+// Response builds the batch envelope. Returns nil if no item failed
+// The summary error is wrapped (and logged) once, on the first call after
+// the last Add
+func (b *Batch) Response() *BatchErrorResponse {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	
+	if len(b.failures) == 0 {
+		return nil
+	}
+	
+	summary := b.summaryLocked()
+	
+	if b.summary == nil {
+		ids := make([]string, len(b.failures))
+		for i, failure := range b.failures {
+			ids[i] = failure.err.ID
+		}
+		
+		b.summary = b.handler.WrapWithDetails(
+			fmt.Errorf("%d of %d items failed", summary.Failed, summary.Total),
+			b.context,
+			map[string]interface{}{
+				"failed_error_ids": ids,
+				"by_code":          summary.ByCode,
+			},
+		)
+	}
+	
+	response := &BatchErrorResponse{
+		ErrorID:   b.summary.ID,
+		Message:   fmt.Sprintf("%d of %d items failed. Please contact support with the error IDs.", summary.Failed, summary.Total),
+		Timestamp: b.summary.Timestamp,
+		Summary:   summary,
+		Errors:    make([]BatchItemError, len(b.failures)),
+	}
+	
+	for i, failure := range b.failures {
+		response.Errors[i] = BatchItemError{
+			Key:     failure.key,
+			ErrorID: failure.err.ID,
+			Code:    ErrorCode(failure.err),
+			Message: b.handler.ErrorResponse(failure.err).Message,
+		}
+	}
+	
+	return response
+}
+
+// WriteResponse writes the batch envelope with the given status code
+// (typically 207 Multi-Status for partial success). Returns false and
+// writes nothing if no item failed
+func (b *Batch) WriteResponse(w http.ResponseWriter, status int) bool {
+	response := b.Response()
+	if response == nil {
+		return false
+	}
+	
+	data, marshalErr := json.Marshal(response)
+	if marshalErr != nil {
+		// Fall back to the standard response for the summary error
+		b.mu.Lock()
+		summary := b.summary
+		b.mu.Unlock()
+		b.handler.writeErrorResponseStatus(w, status, summary)
+		return true
+	}
+	
+	data = append(data, '\n')
+	w.Header().Set("Content-Type", "application/json")
+	b.handler.signResponse(w, data)
+	w.WriteHeader(status)
+	w.Write(data)
+	return true
+}
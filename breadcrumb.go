@@ -0,0 +1,57 @@
+package errorid
+
+import (
+	"context"
+	"time"
+)
+
+// MaxBreadcrumbs is how many breadcrumbs a request keeps; older ones are
+// dropped first
+const MaxBreadcrumbs = 50
+
+// Breadcrumb is a note of something that happened in a request before an
+// error was wrapped, like a cache miss or a retried call
+type Breadcrumb struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// String formats the breadcrumb as "15:04:05.000 message"
+func (b Breadcrumb) String() string {
+	return b.Time.Format("15:04:05.000") + " " + b.Message
+}
+
+// AddBreadcrumb records message in the Collector of ctx. Errors wrapped
+// later with that context carry the request's breadcrumbs, giving a trail
+// of what led to the failure:
+//
+//	errorid.AddBreadcrumb(ctx, "cache miss for key "+key)
+//
+// Without a Collector (see WithCollector and RecoveryMiddleware) it does
+// nothing
+func AddBreadcrumb(ctx context.Context, message string) {
+	if c := CollectorFromContext(ctx); c != nil {
+		c.addBreadcrumb(Breadcrumb{Time: time.Now(), Message: message})
+	}
+}
+
+// Breadcrumbs returns the breadcrumbs recorded so far, oldest first
+func (c *Collector) Breadcrumbs() []Breadcrumb {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.breadcrumbs) == 0 {
+		return nil
+	}
+	return append([]Breadcrumb(nil), c.breadcrumbs...)
+}
+
+// addBreadcrumb appends b, dropping the oldest past MaxBreadcrumbs
+func (c *Collector) addBreadcrumb(b Breadcrumb) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.breadcrumbs) == MaxBreadcrumbs {
+		copy(c.breadcrumbs, c.breadcrumbs[1:])
+		c.breadcrumbs = c.breadcrumbs[:MaxBreadcrumbs-1]
+	}
+	c.breadcrumbs = append(c.breadcrumbs, b)
+}
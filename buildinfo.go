@@ -0,0 +1,60 @@
+package errorid
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// BuildInfo identifies the build that produced an error
+// Read from debug.ReadBuildInfo when a handler is created
+type BuildInfo struct {
+	Version  string // Main module version, e.g. "v1.4.0" or "(devel)"
+	Revision string // VCS revision (vcs.revision)
+	Dirty    bool   // Built from a modified working tree (vcs.modified)
+}
+
+// String formats the build as "version@revision", with "-dirty" appended
+// for modified trees
+func (b *BuildInfo) String() string {
+	s := b.Version
+	if b.Revision != "" {
+		if s != "" {
+			s += "@"
+		}
+		s += b.Revision
+	}
+	if b.Dirty {
+		s += "-dirty"
+	}
+	return s
+}
+
+// currentBuild reads the binary's build info once
+// Nil when the binary carries no version (other than "(devel)") or VCS
+// information
+var currentBuild = sync.OnceValue(func() *BuildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	return parseBuildInfo(info)
+})
+
+// parseBuildInfo extracts the BuildInfo fields from info
+func parseBuildInfo(info *debug.BuildInfo) *BuildInfo {
+	build := &BuildInfo{Version: info.Main.Version}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.modified":
+			build.Dirty = setting.Value == "true"
+		}
+	}
+	
+	// "(devel)" alone (go run, go test) doesn't identify a build
+	if build.Revision == "" && (build.Version == "" || build.Version == "(devel)") {
+		return nil
+	}
+	return build
+}
@@ -0,0 +1,167 @@
+package errorid
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// bundleError is the JSON form of an error in a support bundle
+type bundleError struct {
+	ErrorID     string                 `json:"error_id"`
+	Message     string                 `json:"message"`
+	Context     string                 `json:"context,omitempty"`
+	Code        string                 `json:"code,omitempty"`
+	Timestamp   int64                  `json:"timestamp"`
+	Severity    string                 `json:"severity"`
+	Category    string                 `json:"category,omitempty"`
+	Fingerprint string                 `json:"fingerprint"`
+	IncidentID  string                 `json:"incident_id,omitempty"`
+	Origin      string                 `json:"origin,omitempty"`
+	Build       string                 `json:"build,omitempty"`
+	Component   string                 `json:"component,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+	Related     []string               `json:"related_error_ids,omitempty"`
+	Causes      map[string]string      `json:"causes,omitempty"`
+	Breadcrumbs []Breadcrumb           `json:"breadcrumbs,omitempty"`
+	Trail       []TrailEntry           `json:"trail,omitempty"`
+	Goroutine   uint64                 `json:"goroutine,omitempty"`
+	Correlation map[string]string      `json:"correlation,omitempty"`
+	StackTrace  string                 `json:"stack_trace,omitempty"`
+	PanicStack  string                 `json:"panic_stack,omitempty"`
+}
+
+// bundleManifest describes a support bundle
+type bundleManifest struct {
+	GeneratedAt string   `json:"generated_at"`
+	Build       string   `json:"build,omitempty"`
+	Requested   []string `json:"requested"`
+	Missing     []string `json:"missing,omitempty"` // IDs not in the store
+	Related     []string `json:"related,omitempty"` // Related errors included
+}
+
+// SupportBundle builds a zip for a vendor or support ticket with:
+//
+//	manifest.json          what was requested and found
+//	errors/<ID>.json       each requested error, from Config.Store
+//	related/<ID>.json      errors of the same requests (Related) still stored
+//	config.json            redacted configuration snapshot
+//	stats.json             Handler.Stats
+//
+// IDs missing from the store are listed in the manifest. Fails only if
+// ctx ends or the store fails with something other than ErrNotFound
+func (h *Handler) SupportBundle(ctx context.Context, ids ...string) (io.Reader, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	
+	manifest := bundleManifest{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Requested:   ids,
+	}
+	if h.build != nil {
+		manifest.Build = h.build.String()
+	}
+	
+	included := make(map[string]bool)
+	var related []string
+	for _, id := range ids {
+		if included[id] {
+			continue
+		}
+		err, loadErr := h.Lookup(ctx, id)
+		if errors.Is(loadErr, ErrNotFound) {
+			manifest.Missing = append(manifest.Missing, id)
+			continue
+		}
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		included[id] = true
+		related = append(related, err.Related...)
+		if writeErr := writeZipJSON(zw, "errors/"+id+".json", newBundleError(err)); writeErr != nil {
+			return nil, writeErr
+		}
+	}
+	
+	for _, id := range related {
+		if included[id] {
+			continue
+		}
+		err, loadErr := h.Lookup(ctx, id)
+		if errors.Is(loadErr, ErrNotFound) {
+			continue
+		}
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		included[id] = true
+		manifest.Related = append(manifest.Related, id)
+		if writeErr := writeZipJSON(zw, "related/"+id+".json", newBundleError(err)); writeErr != nil {
+			return nil, writeErr
+		}
+	}
+	
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"manifest.json", manifest},
+		{"config.json", h.configSnapshot()},
+		{"stats.json", h.Stats()},
+	}
+	for _, f := range files {
+		if err := writeZipJSON(zw, f.name, f.v); err != nil {
+			return nil, err
+		}
+	}
+	
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// newBundleError converts err for a support bundle
+func newBundleError(err *ErrorWithID) bundleError {
+	record := bundleError{
+		ErrorID:     err.ID,
+		Message:     errorMessage(err),
+		Context:     err.Context,
+		Code:        ErrorCode(err),
+		Timestamp:   err.Timestamp,
+		Severity:    err.Severity.String(),
+		Category:    err.Category,
+		Fingerprint: err.Fingerprint(),
+		IncidentID:  err.IncidentID,
+		Origin:      err.Origin,
+		Component:   err.Component,
+		Details:     err.Details,
+		Related:     err.Related,
+		Causes:      causeMessages(err.Causes),
+		Breadcrumbs: err.Breadcrumbs,
+		Trail:       err.Trail,
+		Goroutine:   err.Goroutine,
+		Correlation: err.Correlation,
+		StackTrace:  err.StackTrace,
+		PanicStack:  err.PanicStack,
+	}
+	if err.Build != nil {
+		record.Build = err.Build.String()
+	}
+	return record
+}
+
+// writeZipJSON adds v as an indented JSON file to zw
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
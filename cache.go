@@ -0,0 +1,93 @@
+package errorid
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheHinter is implemented by errors whose responses are safe to cache
+// for a while, such as a permanently missing resource
+type CacheHinter interface {
+	CacheTTL() time.Duration
+}
+
+// cacheTTL returns how long err's response may be cached: the CacheTTL of
+// the first CacheHinter in the chain, for 4xx statuses only (0 otherwise)
+func cacheTTL(err *ErrorWithID, status int) time.Duration {
+	if status < 400 || status >= 500 {
+		return 0
+	}
+	var hinter CacheHinter
+	if errors.As(err.Original, &hinter) {
+		return hinter.CacheTTL()
+	}
+	return 0
+}
+
+// errorETag is a weak validator for err's response: it covers status,
+// code and public message but not the ID, so repeated failures of the same
+// resource validate while each occurrence keeps its own ID in logs
+func errorETag(err *ErrorWithID, status int) string {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d\x00%s\x00%s", status, ErrorCode(err), PublicMessage(err))
+	return fmt.Sprintf(`W/"%x"`, hash.Sum64())
+}
+
+// setCacheHeaders sets Cache-Control and ETag for cacheable errors and
+// returns the ETag ("" if err is not cacheable)
+// The body carries a per-request ID (and echoed headers), so only the
+// client may cache it, never a shared cache
+func setCacheHeaders(w http.ResponseWriter, err *ErrorWithID, status int) string {
+	ttl := cacheTTL(err, status)
+	if ttl <= 0 {
+		return ""
+	}
+	etag := errorETag(err, status)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(int64(ttl/time.Second), 10))
+	w.Header().Set("ETag", etag)
+	return etag
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag
+// (weak comparison)
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteErrorRequest is WriteError for a request: Config.EchoHeaders are
+// taken from r and cacheable errors (see CacheHinter) answer a matching
+// If-None-Match with 304 Not Modified
+func WriteErrorRequest(w http.ResponseWriter, r *http.Request, err *ErrorWithID) {
+	defaultFor(2).WriteErrorRequest(w, r, err)
+}
+
+// WriteErrorRequest is WriteError for a request: Config.EchoHeaders are
+// taken from r and cacheable errors (see CacheHinter) answer a matching
+// If-None-Match with 304 Not Modified
+func (h *Handler) WriteErrorRequest(w http.ResponseWriter, r *http.Request, err *ErrorWithID) {
+	if err.Correlation == nil {
+		err.Correlation = h.correlationHeaders(r)
+	}
+	
+	status := responseStatus(err)
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		if inm := r.Header.Get("If-None-Match"); inm != "" {
+			if etag := setCacheHeaders(w, err, status); etag != "" && etagMatches(inm, etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
+	h.writeErrorResponseStatus(w, status, err)
+}
@@ -0,0 +1,123 @@
+package errorid
+
+import (
+	"sort"
+	"strings"
+)
+
+// causesError carries the failures of several named dependencies
+type causesError struct {
+	names  []string // sorted
+	causes map[string]error
+}
+
+// Error lists the failures by dependency, e.g. "inventory: timeout; pricing: 503"
+func (e *causesError) Error() string {
+	parts := make([]string, len(e.names))
+	for i, name := range e.names {
+		parts[i] = name + ": " + e.causes[name].Error()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap returns the failures, so errors.Is and errors.As see each of them
+func (e *causesError) Unwrap() []error {
+	errs := make([]error, len(e.names))
+	for i, name := range e.names {
+		errs[i] = e.causes[name]
+	}
+	return errs
+}
+
+// WithCauses combines the failures of an operation that fans out to
+// several dependencies (scatter-gather) into one error, keeping each under
+// its dependency's name. Wrapping the result puts them in
+// ErrorWithID.Causes, logged as "causes" and shown in responses from
+// ResponseDetailMessage:
+//
+//	causes := map[string]error{"inventory": invErr, "pricing": priceErr}
+//	if err := errorid.WithCauses(causes); err != nil {
+//		return h.Wrap(err, "build product page")
+//	}
+//
+// nil failures are dropped; returns nil if none is left
+func WithCauses(causes map[string]error) error {
+	e := &causesError{causes: make(map[string]error, len(causes))}
+	for name, err := range causes {
+		if err != nil {
+			e.names = append(e.names, name)
+			e.causes[name] = err
+		}
+	}
+	if len(e.names) == 0 {
+		return nil
+	}
+	sort.Strings(e.names)
+	return e
+}
+
+// causesOf collects the named failures in err's chain (WithCauses); outer
+// ones win on duplicate names. Wrapped errors contribute their Causes
+// without being descended into
+func causesOf(err error) map[string]error {
+	var causes map[string]error
+	add := func(name string, cause error) {
+		if causes == nil {
+			causes = make(map[string]error)
+		}
+		if _, ok := causes[name]; !ok {
+			causes[name] = cause
+		}
+	}
+	
+	for stack := []error{err}; len(stack) > 0; {
+		err, stack = stack[0], stack[1:]
+		switch e := err.(type) {
+		case nil:
+			continue
+		case *ErrorWithID:
+			for name, cause := range e.Causes {
+				add(name, cause)
+			}
+			continue
+		case *causesError:
+			for _, name := range e.names {
+				add(name, e.causes[name])
+			}
+			continue
+		}
+	
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			stack = append([]error{e.Unwrap()}, stack...)
+		case interface{ Unwrap() []error }:
+			stack = append(e.Unwrap(), stack...)
+		}
+	}
+	return causes
+}
+
+// storedCauses rebuilds the Causes of a loaded error from their messages
+func storedCauses(messages map[string]string) map[string]error {
+	if len(messages) == 0 {
+		return nil
+	}
+	causes := make(map[string]error, len(messages))
+	for name, message := range messages {
+		causes[name] = &storedError{message: message}
+	}
+	return causes
+}
+
+// causeMessages returns the messages of causes by name, for logs,
+// responses and stores
+func causeMessages(causes map[string]error) map[string]string {
+	if len(causes) == 0 {
+		return nil
+	}
+	messages := make(map[string]string, len(causes))
+	for name, cause := range causes {
+		messages[name] = cause.Error()
+	}
+	return messages
+}
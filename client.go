@@ -0,0 +1,117 @@
+package errorid
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxErrorBodySize caps how much of an error response body ParseResponse reads
+const maxErrorBodySize = 1 << 20
+
+// RemoteError is an error response received from a service using this package
+// It lets Go clients keep the server's error ID for support requests
+type RemoteError struct {
+	ID         string                 // Error ID assigned by the remote service
+	StatusCode int                    // HTTP status code of the response
+	Message    string                 // Message (or problem+json detail/title)
+	Code       string                 // Error code, if the server sent one
+	Retryable  bool                   // Server said the request may be retried
+	RetryAfter time.Duration          // Suggested wait (retry_after_seconds or Retry-After)
+	Timestamp  int64                  // Unix timestamp reported by the server
+	Details    map[string]interface{} // Details, if the server exposed them
+	Type       string                 // problem+json type URI, if any
+}
+
+// Error implements error interface
+func (e *RemoteError) Error() string {
+	if e.ID != "" {
+		return fmt.Sprintf("[%s] remote error (HTTP %d): %s", e.ID, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("remote error (HTTP %d): %s", e.StatusCode, e.Message)
+}
+
+// problemResponse is an RFC 7807 problem+json body with an error_id extension
+type problemResponse struct {
+	Type    string                 `json:"type"`
+	Title   string                 `json:"title"`
+	Detail  string                 `json:"detail"`
+	ErrorID string                 `json:"error_id"`
+	Details map[string]interface{} `json:"details"`
+}
+
+// ParseResponse decodes an error response into a RemoteError
+// Returns nil, nil for non-error responses (status < 400)
+// Understands both ErrorResponse JSON and application/problem+json
+// The body is read (up to 1MB) but not closed; closing stays with the caller
+// Non-JSON error bodies still produce a RemoteError without an ID
+func ParseResponse(resp *http.Response) (*RemoteError, error) {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil, nil
+	}
+	
+	remote := &RemoteError{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+	}
+	
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("errorid: reading error response: %w", err)
+	}
+	
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/problem+json":
+		var problem problemResponse
+		if err := json.Unmarshal(body, &problem); err != nil {
+			return nil, fmt.Errorf("errorid: decoding problem+json response: %w", err)
+		}
+		
+		remote.ID = problem.ErrorID
+		remote.Type = problem.Type
+		remote.Details = problem.Details
+		if problem.Detail != "" {
+			remote.Message = problem.Detail
+		} else if problem.Title != "" {
+			remote.Message = problem.Title
+		}
+		
+	case "application/json":
+		var response ErrorResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("errorid: decoding error response: %w", err)
+		}
+		
+		remote.ID = response.ErrorID
+		remote.Code = response.Code
+		if response.Retryable != nil {
+			remote.Retryable = *response.Retryable
+		}
+		remote.RetryAfter = time.Duration(response.RetryAfter) * time.Second
+		remote.Timestamp = response.Timestamp
+		remote.Details = response.Details
+		if response.Message != "" {
+			remote.Message = response.Message
+		}
+		
+	default:
+		// Plain-text or HTML error pages from proxies, load balancers, etc
+		if len(body) > 0 {
+			remote.Message = string(body)
+		}
+	}
+	
+	// Retry-After (seconds form) covers proxies and problem+json bodies
+	if remote.RetryAfter == 0 {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			remote.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	
+	return remote, nil
+}
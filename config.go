@@ -1,9 +1,12 @@
 package errorid
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
+	"time"
 )
 
 // Config holds configuration for error handler
@@ -16,21 +19,298 @@ type Config struct {
 	// true = non-blocking, false = blocking
 	AsyncCallback bool
 
+	// CallbackTimeout bounds how long a sync OnError call blocks the wrap
+	// (async calls are only measured). Zero means no limit
+	CallbackTimeout time.Duration
+
+	// MaxPendingCallbacks caps in-flight async OnError calls; beyond it
+	// callbacks are dropped and counted. Zero means no limit
+	MaxPendingCallbacks int
+
+	// CallbackQueueSize lets up to this many async OnError calls wait for
+	// a MaxPendingCallbacks slot instead of being dropped. They run most
+	// severe first; when the queue is full the least severe call is shed
+	// (Stats.CallbackShed). Critical errors never wait. Zero disables it
+	CallbackQueueSize int
+
+	// OnCallbackError is called when OnError panics, times out or is
+	// dropped. cause wraps ErrCallbackPanic, ErrCallbackTimeout or
+	// ErrCallbackDropped. Counters are available from Handler.Stats
+	OnCallbackError func(err *ErrorWithID, cause error)
+
+	// IncidentThreshold enables incident grouping: once this many errors
+	// with the same Fingerprint are wrapped within IncidentWindow, they
+	// share an ErrorWithID.IncidentID until the burst has been quiet for
+	// a window. Zero disables it
+	IncidentThreshold int
+
+	// IncidentWindow is the burst detection window (default 1 minute)
+	IncidentWindow time.Duration
+
+	// DoubleReportGuard collapses re-wraps of a failure already wrapped in
+	// the same request (an inner handler wraps, then middleware wraps
+	// again): the earlier error is returned with the new context added to
+	// its Contexts, and nothing is logged or sent again. Failures are the
+	// same when the new error's chain holds the earlier ErrorWithID or its
+	// Original error value. Needs a request context (WrapContext)
+	DoubleReportGuard bool
+
+	// RecordTrail records every wrap of an error in ErrorWithID.Trail
+	// (context, time, wrap site), carried over when an ErrorWithID is
+	// wrapped again, so deep stacks show the path the error took
+	RecordTrail bool
+	
+	// AnnotateTrace records the wrapping goroutine's ID in
+	// ErrorWithID.Goroutine and, while a runtime/trace is being collected,
+	// logs each error ID and opens a region named after its context, so
+	// execution traces taken during incidents show where errors occurred
+	AnnotateTrace bool
+	
+	// WrapOnceWindow is how long after a failed attempt WrapOnce still
+	// treats a failure of the same operation as a retry (default 1 minute)
+	WrapOnceWindow time.Duration
+	
+	// FloodThreshold protects against error floods from one client: past
+	// this many errors within FloodWindow (default 1 minute), errors wrapped
+	// with a RecoveryMiddleware request context reuse the client's last
+	// error's ID (no log or OnError) until the window ends. Critical
+	// errors are always wrapped. Clients are told apart by the peer address
+	// (http.Request.RemoteAddr): behind a proxy, rewrite RemoteAddr from
+	// the forwarded client IP first, or all clients share one budget
+	// Zero disables it
+	FloodThreshold int
+	FloodWindow    time.Duration
+
+	// TelemetryEndpoint enables telemetry: aggregate wrap counts (never
+	// error payloads) are POSTed as a TelemetryReport every
+	// TelemetryInterval (default 1 minute) and once more on Close
+	TelemetryEndpoint string
+	TelemetryInterval time.Duration
+
+	// TelemetryService names this service in telemetry reports
+	TelemetryService string
+
+	// RetryHints gives the retry behavior sent to clients per Category,
+	// for errors whose chain has no RetryHinter
+	RetryHints map[string]RetryHint
+
+	// Store saves every reported error for lookup by ID (Handler.Lookup)
+	// Saves are synchronous; wrap slow stores in NewWriteBehindStore
+	Store Store
+
+	// IDLog durably records the ID, timestamp and fingerprint of every
+	// error, sampled or not, so IDs can be confirmed without a Store
+	// (Handler.ConfirmID). Open it with OpenIDLog
+	IDLog *IDLog
+
+	// CrashDir is where Fatal writes <ID>.json crash reports
+	// Empty disables crash files
+	CrashDir string
+
+	// DefaultDetails are added to the Details of every error; details
+	// passed to Wrap take precedence. See KubernetesDetails
+	DefaultDetails map[string]interface{}
+
+	// EnvDetails is an allowlist of environment variables (e.g. "REGION",
+	// "DEPLOYMENT_ID") read once by New and added to DefaultDetails under
+	// their lowercased names. Other variables are never captured
+	EnvDetails []string
+
+	// DisableBuildInfo stops stamping ErrorWithID.Build with the
+	// version and VCS revision from debug.ReadBuildInfo
+	DisableBuildInfo bool
+
 	// Logger for error logging. If nil, uses default logger
 	Logger Logger
 
 	// IncludeStackTrace adds stack trace to error details
 	IncludeStackTrace bool
 
+	// StackTraceTargets picks where captured stacks (StackTrace and
+	// PanicStack) go; zero means StackToAll. StackToLogs alone gives
+	// "log stacks, never return them"
+	StackTraceTargets StackTargets
+
+	// StackFormat picks text or []StackFrame encoding of stacks in logs
+	// and responses (default StackFormatText)
+	StackFormat StackFormat
+
+	// IncludeOrigin records the wrap site ("file:line function") in
+	// ErrorWithID.Origin. Far cheaper than a stack trace, so it can stay
+	// on even when IncludeStackTrace is off
+	IncludeOrigin bool
+
+	// InferContext names errors wrapped with an empty context (Newf, Try,
+	// HTTPError, Wrap(err, "")) after the calling function, e.g.
+	// "orders.Service.Place", so they still group meaningfully
+	InferContext bool
+
 	// Environment affects detail level in responses
 	// "production" = minimal details, "development" = full details
+	// Other names (e.g. "staging") get the production defaults
 	Environment string
 
+	// Component names the subsystem this handler serves (e.g. "billing")
+	// when a process hosts several handlers. Its errors carry it
+	// (ErrorWithID.Component): it is logged, stored, prefixed to
+	// DefaultLogger lines and added to metrics and APM tags
+	Component string
+
+	// ResponseDetail controls how much of the error HTTP responses expose
+	// Zero value derives it from Environment (see ResponseDetailDefault)
+	ResponseDetail ResponseDetail
+
 	// IDGenerator custom function to generate error IDs
 	// If nil, uses default generator
 	IDGenerator func() string
+
+	// IDPool, if set, supplies the random part of default-generator IDs
+	// from its buffer; dates still follow TimeLocation
+	// Ignored when IDGenerator is set
+	IDPool *IDPool
+
+	// IDMode selects whether wrapped errors get their own ID (default),
+	// share one per request, or get none (operator mode for internal tools
+	// that want logging and callbacks without customer-facing IDs)
+	IDMode IDMode
+
+	// IDFallback fills the random part of IDs when crypto/rand fails
+	// (default generator only). Nil uses MathRandFallback
+	IDFallback IDFallback
+
+	// TimeLocation is the time zone for the date part of generated IDs,
+	// the "time" field of JSON responses and default logger timestamps
+	// If nil, IDs and logs use local time and responses omit "time"
+	TimeLocation *time.Location
+
+	// ErrorFormat is a text/template for ErrorWithID.Error(), executed with
+	// the ErrorWithID fields (not methods) as data, e.g.
+	// "{{.Context}}: {{.Original}} ({{.ID}})"
+	// Empty uses the default "[ID] context: error" layout
+	ErrorFormat string
+
+	// EchoHeaders are request headers (e.g. "X-Request-ID", "traceparent")
+	// reflected in error responses as "correlation", so clients that keep
+	// only the body can still give support everything needed to join logs
+	// and traces. Applies to errors wrapped with a RecoveryMiddleware
+	// request context and to WriteErrorRequest
+	EchoHeaders []string
+
+	// CaptureRequests keeps the method, URL, headers and body (up to
+	// MaxCapturedBody) of requests whose errors are wrapped with a
+	// RecoveryMiddleware request context, in ErrorWithID.Request and for
+	// ReplayHandler. Only honored in the "development" Environment
+	CaptureRequests bool
+	
+	// SanitizePanic formats recovered panic values (non-error ones) for
+	// messages, logs and responses instead of fmt.Sprint. See PanicSanitizer
+	SanitizePanic func(value interface{}) string
+	
+	// Flags is asked on every wrap for per-error overrides (stack capture,
+	// sampling, extra notifiers), e.g. backed by a feature flag service
+	Flags FlagProvider
+	
+	// ResponseExtensions render Details keys as extra response fields for
+	// errors of given codes or categories (e.g. "fields" of validation
+	// errors), at every ResponseDetail level
+	ResponseExtensions []ResponseExtension
+	
+	// ErrorIDCookie, if set, also sends error IDs in a last_error_id cookie
+	// (readable by scripts) expiring after this long, for single-page apps
+	// whose fetch layer hides response bodies. Keep it short (e.g. 1 minute)
+	ErrorIDCookie time.Duration
+	
+	// ResponseSigner, if set, signs error response bodies and sends the
+	// detached signature in an Error-Signature header, so gateways and
+	// clients can check the error came from this service (VerifyResponse)
+	ResponseSigner ResponseSigner
+	
+	// LazyDetailTimeout bounds the evaluation of Lazy detail values of a
+	// reported error (default 100ms); slower ones are logged as timed out
+	LazyDetailTimeout time.Duration
+	
+	// TenantKey is the Details key holding the affected tenant, used by
+	// FindDuplicates (default "tenant")
+	TenantKey string
+	
+	// RemoteAddrFilter transforms client addresses before middleware stores
+	// them in Details ("remote"). Use MaskIP or HashIP to comply with
+	// privacy policies. If nil, the full address is stored
+	RemoteAddrFilter func(addr string) string
+
+	// Translator rewrites upstream errors into internal Definitions
+	// before they are wrapped (see NewTranslator)
+	Translator *Translator
+
+	// Interceptors run around the reporting of every wrapped error
+	// The first interceptor is the outermost one. Use them for
+	// enrichment, redaction, sampling or metrics
+	Interceptors []Interceptor
+}
+
+// StackTargets is a set of destinations for captured stack traces
+type StackTargets int
+
+const (
+	StackToLogs      StackTargets = 1 << iota // Logger.Error stackTrace argument
+	StackToCallbacks                          // ErrorWithID passed to OnError
+	StackToResponses                          // stack_trace / panic_stack at ResponseDetailFull
+	
+	StackToAll = StackToLogs | StackToCallbacks | StackToResponses
+)
+
+// has reports whether target is in t (zero t means all targets)
+func (t StackTargets) has(target StackTargets) bool {
+	return t == 0 || t&target != 0
 }
 
+// IDMode controls ID assignment for wrapped errors
+type IDMode int
+
+const (
+	// IDPerError gives every wrapped error a fresh ID
+	IDPerError IDMode = iota
+
+	// IDPerRequest gives errors wrapped with the same request context (see
+	// Collector) the ID of the first one; others get a fresh ID
+	// Stores key errors by ID, so it can't be combined with Config.Store
+	IDPerRequest
+
+	// IDNone leaves ErrorWithID.ID empty; errors are still logged and
+	// passed to OnError
+	IDNone
+)
+
+// ResponseDetail is the verbosity level of HTTP error responses
+type ResponseDetail int
+
+const (
+	// ResponseDetailDefault picks ResponseDetailMessage in "development"
+	// and ResponseDetailMinimal otherwise
+	ResponseDetailDefault ResponseDetail = iota
+
+	// ResponseDetailMinimal returns only the error ID and a generic message
+	ResponseDetailMinimal
+
+	// ResponseDetailMessage adds the full error message
+	ResponseDetailMessage
+
+	// ResponseDetailDetails adds the error Details map
+	ResponseDetailDetails
+
+	// ResponseDetailFull adds the stack trace (if captured)
+	ResponseDetailFull
+)
+
+// WrapFunc reports a wrapped error (logging and OnError) and returns it
+// The error already carries its ID, timestamp and stack trace
+type WrapFunc func(err *ErrorWithID) *ErrorWithID
+
+// Interceptor decorates a WrapFunc, like HTTP middleware
+// Modify err before calling next to enrich or redact it, skip next to
+// suppress reporting, or inspect the result after next returns
+type Interceptor func(next WrapFunc) WrapFunc
+
 // Logger interface for custom logging implementations
 type Logger interface {
 	// Error logs an error with ID, context, and optional details
@@ -39,6 +319,22 @@ type Logger interface {
 	Info(msg string)
 }
 
+// Field is a handler-added log field (timestamp, origin, severity, ...)
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// FieldLogger is a Logger that takes the handler's fields apart from the
+// error's details, sparing the handler a copy of the details map for every
+// logged error. details is the error's own map and must not be modified;
+// neither it nor fields may be retained after ErrorFields returns. A field
+// replaces a detail of the same key
+type FieldLogger interface {
+	Logger
+	ErrorFields(errorID string, err error, context string, details map[string]interface{}, fields []Field, stackTrace string)
+}
+
 // DefaultConfig returns sensible default configuration
 func DefaultConfig() Config {
 	return Config{
@@ -51,6 +347,30 @@ func DefaultConfig() Config {
 	}
 }
 
+// Validate reports settings that would make the handler misbehave
+// Returned errors wrap ErrInvalidConfig
+func (c Config) Validate() error {
+	if c.ErrorFormat != "" {
+		if _, err := parseErrorFormat(c.ErrorFormat); err != nil {
+			return fmt.Errorf("%w: ErrorFormat: %v", ErrInvalidConfig, err)
+		}
+	}
+	
+	if c.ResponseDetail < ResponseDetailDefault || c.ResponseDetail > ResponseDetailFull {
+		return fmt.Errorf("%w: unknown ResponseDetail %d", ErrInvalidConfig, c.ResponseDetail)
+	}
+	
+	if c.IDMode < IDPerError || c.IDMode > IDNone {
+		return fmt.Errorf("%w: unknown IDMode %d", ErrInvalidConfig, c.IDMode)
+	}
+	
+	if c.IDMode == IDPerRequest && c.Store != nil {
+		return fmt.Errorf("%w: IDPerRequest with a Store (errors of a request would share a store key)", ErrInvalidConfig)
+	}
+	
+	return nil
+}
+
 // defaultOnError is the default callback that just logs
 func defaultOnError(err *ErrorWithID) {
 	// Logging is handled separately, so this is just a no-op placeholder
@@ -59,7 +379,9 @@ func defaultOnError(err *ErrorWithID) {
 
 // DefaultLogger implements Logger interface using standard log package
 type DefaultLogger struct {
-	logger *log.Logger
+	logger    *log.Logger
+	loc       *time.Location // timestamp zone, nil = log package default
+	component string         // "[component] " line prefix (Config.Component)
 }
 
 // NewDefaultLogger creates a new default logger
@@ -69,19 +391,119 @@ func NewDefaultLogger(out io.Writer) *DefaultLogger {
 	}
 }
 
+// NewDefaultLoggerIn creates a default logger with timestamps in loc
+func NewDefaultLoggerIn(out io.Writer, loc *time.Location) *DefaultLogger {
+	return &DefaultLogger{
+		logger: log.New(out, "", 0),
+		loc:    loc,
+	}
+}
+
+// In returns a logger writing to the same output with timestamps in loc
+func (l *DefaultLogger) In(loc *time.Location) *DefaultLogger {
+	in := NewDefaultLoggerIn(l.logger.Writer(), loc)
+	in.component = l.component
+	return in
+}
+
+// Named returns a logger writing to the same output with lines prefixed
+// by "[component] "
+func (l *DefaultLogger) Named(component string) *DefaultLogger {
+	named := *l
+	named.component = component
+	return &named
+}
+
+// printf writes a log line, formatting the timestamp in l.loc if set
+func (l *DefaultLogger) printf(format string, args ...interface{}) {
+	if l.component != "" {
+		format = "[" + l.component + "] " + format
+	}
+	if l.loc == nil {
+		l.logger.Printf(format, args...)
+		return
+	}
+	
+	// Same layout as log.LstdFlags with the "[ERROR-ID] " prefix
+	l.logger.Printf("[ERROR-ID] "+time.Now().In(l.loc).Format("2006/01/02 15:04:05")+" "+format, args...)
+}
+
 // Error logs error with ID and context
 func (l *DefaultLogger) Error(errorID string, err error, context string, details map[string]interface{}, stackTrace string) {
 	if stackTrace != "" {
 		// Include stack trace in log output
-		l.logger.Printf("ID=%s | Context=%s | Error=%v | Details=%+v | StackTrace=%s", 
+		l.printf("ID=%s | Context=%s | Error=%v | Details=%+v | StackTrace=%s", 
 			errorID, context, err, details, stackTrace)
 	} else {
-		l.logger.Printf("ID=%s | Context=%s | Error=%v | Details=%+v", 
+		l.printf("ID=%s | Context=%s | Error=%v | Details=%+v", 
 			errorID, context, err, details)
 	}
 }
 
+// ErrorFields logs like Error with fields merged into details, without
+// building the merged map
+func (l *DefaultLogger) ErrorFields(errorID string, err error, context string, details map[string]interface{}, fields []Field, stackTrace string) {
+	merged := mergedDetails{details: details, fields: fields}
+	if stackTrace != "" {
+		l.printf("ID=%s | Context=%s | Error=%v | Details=%+v | StackTrace=%s", 
+			errorID, context, err, merged, stackTrace)
+	} else {
+		l.printf("ID=%s | Context=%s | Error=%v | Details=%+v", 
+			errorID, context, err, merged)
+	}
+}
+
+// mergedDetails formats details with fields merged in, exactly as fmt
+// formats the merged map
+type mergedDetails struct {
+	details map[string]interface{}
+	fields  []Field
+}
+
+func (m mergedDetails) Format(f fmt.State, verb rune) {
+	keys := make([]string, 0, len(m.details)+len(m.fields))
+	for i, field := range m.fields {
+		if _, dup := m.field(field.Key, i); !dup {
+			keys = append(keys, field.Key)
+		}
+	}
+	for key := range m.details {
+		if _, isField := m.field(key, len(m.fields)); !isField {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	
+	format := "%" + string(verb)
+	if f.Flag('+') {
+		format = "%+" + string(verb)
+	}
+	io.WriteString(f, "map[")
+	for i, key := range keys {
+		if i > 0 {
+			io.WriteString(f, " ")
+		}
+		value, isField := m.field(key, len(m.fields))
+		if !isField {
+			value = m.details[key]
+		}
+		io.WriteString(f, key+":")
+		fmt.Fprintf(f, format, value)
+	}
+	io.WriteString(f, "]")
+}
+
+// field returns the last value of key among the first n fields
+func (m mergedDetails) field(key string, n int) (interface{}, bool) {
+	for i := n - 1; i >= 0; i-- {
+		if m.fields[i].Key == key {
+			return m.fields[i].Value, true
+		}
+	}
+	return nil, false
+}
+
 // Info logs informational message
 func (l *DefaultLogger) Info(msg string) {
-	l.logger.Printf("INFO: %s", msg)
+	l.printf("INFO: %s", msg)
 }
@@ -0,0 +1,199 @@
+package errorid
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// collectorKey is the context key for the request's Collector
+type collectorKey struct{}
+
+// detailsKey is the context key for details added with WithDetails
+type detailsKey struct{}
+
+// Collector gathers every error wrapped with a context during one request
+// RecoveryMiddleware installs one per request; errors wrapped with
+// WrapContext then reference each other through ErrorWithID.Related
+type Collector struct {
+	mu     sync.Mutex
+	errors []*ErrorWithID
+	id     string // shared error ID (IDPerRequest)
+	
+	breadcrumbs []Breadcrumb // AddBreadcrumb, oldest first
+	policy      *Policy      // WithPolicy on an inner context
+}
+
+// WithCollector returns a context carrying a new Collector
+// If ctx already has one, it is returned unchanged
+func WithCollector(ctx context.Context) (context.Context, *Collector) {
+	if c := CollectorFromContext(ctx); c != nil {
+		return ctx, c
+	}
+	c := &Collector{}
+	return context.WithValue(ctx, collectorKey{}, c), c
+}
+
+// CollectorFromContext returns the Collector of ctx, or nil
+func CollectorFromContext(ctx context.Context) *Collector {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+// Errors returns the errors collected so far, in wrap order
+func (c *Collector) Errors() []*ErrorWithID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*ErrorWithID(nil), c.errors...)
+}
+
+// IDs returns the IDs of the errors collected so far, in wrap order
+func (c *Collector) IDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.idsLocked()
+}
+
+// idsLocked returns collected IDs, c.mu must be held
+func (c *Collector) idsLocked() []string {
+	if len(c.errors) == 0 {
+		return nil
+	}
+	ids := make([]string, len(c.errors))
+	for i, err := range c.errors {
+		ids[i] = err.ID
+	}
+	return ids
+}
+
+// add records err and returns the IDs collected before it
+func (c *Collector) add(err *ErrorWithID) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	related := c.idsLocked()
+	c.errors = append(c.errors, err)
+	return related
+}
+
+// collapse returns the collected error err is a re-wrap of: one in err's
+// chain, or one whose Original is in err's chain. context is recorded in
+// its Contexts (and entry, if not nil, to its Trail). Returns nil if err
+// is a new failure
+func (c *Collector) collapse(err error, context string, entry *TrailEntry) *ErrorWithID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	for _, collected := range c.errors {
+		if errors.Is(err, collected) || (collected.Original != nil && errors.Is(err, collected.Original)) {
+			collected.Contexts = append(collected.Contexts, context)
+			if entry != nil {
+				entry.ErrorID = collected.ID
+				collected.Trail = append(collected.Trail, *entry)
+			}
+			return collected
+		}
+	}
+	return nil
+}
+
+// requestID returns the request's shared error ID, generating it once
+func (c *Collector) requestID(generate func() string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.id == "" {
+		c.id = generate()
+	}
+	return c.id
+}
+
+// WrapContext wraps an error using the default handler and records it in
+// the Collector of ctx (if any)
+func WrapContext(ctx context.Context, err error, context string) *ErrorWithID {
+	return defaultFor(2).WrapWithDetailsContext(ctx, err, context, nil)
+}
+
+// WrapWithDetailsContext is WrapWithDetails with a request context
+func WrapWithDetailsContext(ctx context.Context, err error, context string, details map[string]interface{}) *ErrorWithID {
+	return defaultFor(2).WrapWithDetailsContext(ctx, err, context, details)
+}
+
+// WrapContext wraps an error and records it in the Collector of ctx (if any)
+// The error's Related field lists errors wrapped earlier in the same request
+func (h *Handler) WrapContext(ctx context.Context, err error, context string) *ErrorWithID {
+	return h.wrap(ctx, err, context, nil)
+}
+
+// WrapWithDetailsContext is WrapWithDetails with a request context
+func (h *Handler) WrapWithDetailsContext(ctx context.Context, err error, context string, details map[string]interface{}) *ErrorWithID {
+	return h.wrap(ctx, err, context, details)
+}
+
+// WithDetails returns a context whose wrapped errors (WrapContext and
+// friends) carry details, on top of details already in ctx. Details
+// passed to the wrap call take precedence
+func WithDetails(ctx context.Context, details map[string]interface{}) context.Context {
+	parent := DetailsFromContext(ctx)
+	merged := make(map[string]interface{}, len(parent)+len(details))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range details {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, detailsKey{}, merged)
+}
+
+// DetailsFromContext returns the details added to ctx with WithDetails
+// The map must not be modified
+func DetailsFromContext(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	details, _ := ctx.Value(detailsKey{}).(map[string]interface{})
+	return details
+}
+
+// Captured is the error context of a request (its Collector, details and
+// echoed correlation headers), detached from the request's cancellation so it can cross goroutine and
+// channel hops:
+//
+//	jobs <- job{payload, errorid.Capture(r.Context())}
+//	// in the worker
+//	ctx := job.errors.Restore(context.Background())
+//	h.WrapContext(ctx, err, "process job") // inherits details, correlation and Related
+type Captured struct {
+	collector   *Collector
+	details     map[string]interface{}
+	correlation map[string]string
+}
+
+// Capture returns the error context of ctx
+func Capture(ctx context.Context) Captured {
+	return Captured{
+		collector:   CollectorFromContext(ctx),
+		details:     DetailsFromContext(ctx),
+		correlation: correlationFrom(ctx),
+	}
+}
+
+// Restore returns ctx carrying the captured error context. Details and
+// correlation already in ctx take precedence over captured ones
+func (c Captured) Restore(ctx context.Context) context.Context {
+	if c.collector != nil && CollectorFromContext(ctx) == nil {
+		ctx = context.WithValue(ctx, collectorKey{}, c.collector)
+	}
+	if c.correlation != nil && correlationFrom(ctx) == nil {
+		ctx = context.WithValue(ctx, correlationKey{}, c.correlation)
+	}
+	if len(c.details) > 0 {
+		existing := DetailsFromContext(ctx)
+		ctx = context.WithValue(ctx, detailsKey{}, c.details)
+		if len(existing) > 0 {
+			ctx = WithDetails(ctx, existing)
+		}
+	}
+	return ctx
+}
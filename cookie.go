@@ -0,0 +1,28 @@
+package errorid
+
+import (
+	"net/http"
+	"time"
+)
+
+// LastErrorIDCookie is the cookie set by Config.ErrorIDCookie
+const LastErrorIDCookie = "last_error_id"
+
+// setErrorIDCookie sets the last_error_id cookie for err if enabled
+// It is readable from JavaScript (no HttpOnly), so single-page apps can
+// show the ID even if their fetch layer dropped the response body
+func (h *Handler) setErrorIDCookie(w http.ResponseWriter, err *ErrorWithID) {
+	maxAge := h.config.ErrorIDCookie
+	if maxAge <= 0 || err.ID == "" {
+		return
+	}
+	
+	http.SetCookie(w, &http.Cookie{
+		Name:     LastErrorIDCookie,
+		Value:    err.ID,
+		Path:     "/",
+		MaxAge:   int(maxAge / time.Second),
+		Expires:  time.Now().Add(maxAge),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
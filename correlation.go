@@ -0,0 +1,54 @@
+package errorid
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// correlationKey is the context key for echoed request headers
+type correlationKey struct{}
+
+// maxCorrelationValue caps echoed header values
+const maxCorrelationValue = 256
+
+// correlationHeaders returns the Config.EchoHeaders present in r, keyed by
+// lowercase name
+func (h *Handler) correlationHeaders(r *http.Request) map[string]string {
+	if len(h.config.EchoHeaders) == 0 {
+		return nil
+	}
+	
+	var headers map[string]string
+	for _, name := range h.config.EchoHeaders {
+		value := r.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if len(value) > maxCorrelationValue {
+			value = value[:maxCorrelationValue]
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(h.config.EchoHeaders))
+		}
+		headers[strings.ToLower(name)] = value
+	}
+	return headers
+}
+
+// withCorrelation returns ctx carrying r's echoed headers
+func (h *Handler) withCorrelation(ctx context.Context, r *http.Request) context.Context {
+	if headers := h.correlationHeaders(r); headers != nil {
+		return context.WithValue(ctx, correlationKey{}, headers)
+	}
+	return ctx
+}
+
+// correlationFrom returns the echoed headers of ctx, or nil
+func correlationFrom(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	headers, _ := ctx.Value(correlationKey{}).(map[string]string)
+	return headers
+}
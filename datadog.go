@@ -0,0 +1,157 @@
+package errorid
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// DatadogExporter sends errors to Datadog Error Tracking through the logs
+// intake, using the error.* attributes Error Tracking groups on (including
+// error.fingerprint, so issues follow ErrorWithID.Fingerprint)
+//
+//	datadog := &errorid.DatadogExporter{APIKey: key, Service: "api", Env: "prod"}
+//	errorid.Configure(errorid.Config{OnError: datadog.OnError, AsyncCallback: true})
+type DatadogExporter struct {
+	APIKey     string
+	Site       string   // Datadog site, default "datadoghq.com"
+	Service    string
+	Env        string
+	Tags       []string // Extra "key:value" tags
+	Hostname   string   // Default os.Hostname()
+	APIURL     string   // Overrides the intake URL derived from Site
+	HTTPClient *http.Client
+}
+
+// datadogLog is one entry of the logs intake payload
+type datadogLog struct {
+	Message  string                 `json:"message"`
+	Status   string                 `json:"status"`
+	Service  string                 `json:"service,omitempty"`
+	Source   string                 `json:"ddsource"`
+	Tags     string                 `json:"ddtags,omitempty"`
+	Hostname string                 `json:"hostname,omitempty"`
+	Date     int64                  `json:"date"` // milliseconds
+	Error    datadogError           `json:"error"`
+	ErrorID  string                 `json:"error_id"`
+	Context  string                 `json:"context,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// datadogError holds the Error Tracking attributes
+type datadogError struct {
+	Kind        string `json:"kind"`
+	Message     string `json:"message"`
+	Stack       string `json:"stack,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Notify sends err as an error log
+func (e *DatadogExporter) Notify(ctx context.Context, err *ErrorWithID) error {
+	intake := e.APIURL
+	if intake == "" {
+		site := e.Site
+		if site == "" {
+			site = "datadoghq.com"
+		}
+		intake = "https://http-intake.logs." + site + "/api/v2/logs"
+	}
+	
+	header := http.Header{}
+	header.Set("DD-API-KEY", e.APIKey)
+	
+	return postJSON(ctx, e.HTTPClient, intake, header, []datadogLog{e.log(err)})
+}
+
+// OnError is a Config.OnError callback; delivery errors are dropped
+func (e *DatadogExporter) OnError(err *ErrorWithID) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	
+	e.Notify(ctx, err)
+}
+
+// log converts err into a logs intake entry
+func (e *DatadogExporter) log(err *ErrorWithID) datadogLog {
+	hostname := e.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	
+	tags := append([]string(nil), e.Tags...)
+	if e.Env != "" {
+		tags = append(tags, "env:"+e.Env)
+	}
+	if err.Build != nil && err.Build.Version != "" {
+		tags = append(tags, "version:"+err.Build.Version)
+	}
+	if err.Component != "" {
+		tags = append(tags, "component:"+err.Component)
+	}
+	
+	status := "error"
+	switch err.Severity {
+	case SeverityCritical:
+		status = "critical"
+	case SeverityWarning:
+		status = "warn"
+	case SeverityInfo:
+		status = "info"
+	}
+	
+	return datadogLog{
+		Message:  err.Error(),
+		Status:   status,
+		Service:  e.Service,
+		Source:   "go",
+		Tags:     strings.Join(tags, ","),
+		Hostname: hostname,
+		Date:     err.Timestamp * 1000,
+		Error: datadogError{
+			Kind:        errorKind(err),
+			Message:     errorMessage(err),
+			Stack:       goroutineStack(topStack(err)),
+			Fingerprint: err.Fingerprint(),
+		},
+		ErrorID: err.ID,
+		Context: err.Context,
+		Details: err.Details,
+	}
+}
+
+// topStack is the panic site stack of recovered panics, else the wrap-site stack
+func topStack(err *ErrorWithID) string {
+	if err.PanicStack != "" {
+		return err.PanicStack
+	}
+	return err.StackTrace
+}
+
+// errorKind is the error code, or the innermost error type
+func errorKind(err *ErrorWithID) string {
+	if code := ErrorCode(err); code != "" {
+		return code
+	}
+	return errorType(err.Original)
+}
+
+// goroutineStack turns a captured stack ("func\n\tfile:line\n" per frame)
+// into the runtime's panic layout, which Datadog parses into frames
+// The goroutine header is a placeholder: the wrapping goroutine isn't known
+func goroutineStack(stack string) string {
+	if stack == "" {
+		return ""
+	}
+	
+	var b strings.Builder
+	b.WriteString("goroutine 1 [running]:\n")
+	for _, line := range strings.Split(strings.TrimRight(stack, "\n"), "\n") {
+		if strings.HasPrefix(line, "\t") {
+			b.WriteString(line + "\n")
+		} else {
+			b.WriteString(line + "(...)\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package errorid
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// DebugConfigPath is the suggested route for ConfigHandler
+const DebugConfigPath = "/debug/errorid/config"
+
+// ConfigHandler serves the handler's effective configuration and stats as
+// JSON, secrets masked (see SupportBundle's config.json), to debug "why
+// wasn't this error reported?" in a running service. Requests for which
+// allow returns false get 404, so the route isn't advertised; a nil allow
+// denies everything:
+//
+//	mux.Handle(errorid.DebugConfigPath, handler.ConfigHandler(isAdmin))
+func (h *Handler) ConfigHandler(allow func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allow == nil || !allow(r) {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"config": h.configSnapshot(),
+			"stats":  h.Stats(),
+		})
+	})
+}
+
+// configSnapshot describes the handler's configuration without secrets:
+// functions and interfaces are reported as set or not, endpoint URLs are
+// reduced to scheme and host, and detail values are left out
+func (h *Handler) configSnapshot() map[string]interface{} {
+	c := h.config
+	
+	return map[string]interface{}{
+		"environment":           c.Environment,
+		"component":             c.Component,
+		"response_detail":       int(h.responseDetail()),
+		"async_callback":        c.AsyncCallback,
+		"callback_timeout":      c.CallbackTimeout.String(),
+		"max_pending_callbacks": c.MaxPendingCallbacks,
+		"callback_queue_size":   c.CallbackQueueSize,
+		"include_stack_trace":   c.IncludeStackTrace,
+		"stack_trace_targets":   int(c.StackTraceTargets),
+		"stack_format":          int(c.StackFormat),
+		"include_origin":        c.IncludeOrigin,
+		"infer_context":         c.InferContext,
+		"id_mode":               int(c.IDMode),
+		"error_format":          c.ErrorFormat,
+		"incident_threshold":    c.IncidentThreshold,
+		"incident_window":       c.IncidentWindow.String(),
+		"flood_threshold":       c.FloodThreshold,
+		"flood_window":          c.FloodWindow.String(),
+		"wrap_once_window":      c.WrapOnceWindow.String(),
+		"record_trail":          c.RecordTrail,
+		"annotate_trace":        c.AnnotateTrace,
+		"lazy_detail_timeout":   c.LazyDetailTimeout.String(),
+		"tenant_key":            c.TenantKey,
+		"env_details":           c.EnvDetails,
+		"telemetry_endpoint":    redactURL(c.TelemetryEndpoint),
+		"telemetry_interval":    c.TelemetryInterval.String(),
+		"crash_dir_set":         c.CrashDir != "",
+		"default_detail_keys":   mapKeys(c.DefaultDetails),
+		"disable_build_info":    c.DisableBuildInfo,
+		"interceptors":          len(c.Interceptors),
+		"on_error_set":          c.OnError != nil,
+		"logger":                typeName(c.Logger),
+		"store":                 typeName(c.Store),
+		"id_log_set":            c.IDLog != nil,
+		"translator_set":        c.Translator != nil,
+		"double_report_guard":   c.DoubleReportGuard,
+		"echo_headers":          c.EchoHeaders,
+		"capture_requests":      c.CaptureRequests,
+		"retry_hint_categories": mapKeys(c.RetryHints),
+		"response_extensions":   len(c.ResponseExtensions),
+		"remote_addr_filter":    c.RemoteAddrFilter != nil,
+		"sanitize_panic":        c.SanitizePanic != nil,
+		"flag_provider":         typeName(c.Flags),
+		"error_id_cookie":       c.ErrorIDCookie.String(),
+		"response_signer":       typeName(c.ResponseSigner),
+	}
+}
+
+// redactURL keeps only the scheme and host of raw, which may carry tokens
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "[redacted]"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// typeName returns the dynamic type of v ("" for nil), naming what is
+// configured without exposing its contents
+func typeName(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// mapKeys returns the sorted keys of m
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
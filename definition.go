@@ -0,0 +1,153 @@
+package errorid
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// StatusCoder is implemented by errors that map to an HTTP status
+type StatusCoder interface {
+	HTTPStatus() int
+}
+
+// PublicMessager is implemented by errors with a message safe to show
+// to clients at any ResponseDetail level
+type PublicMessager interface {
+	PublicMessage() string
+}
+
+// HTTPStatus returns the status of the first error in err's chain that
+// implements StatusCoder, or 0 if there is none
+func HTTPStatus(err error) int {
+	var coder StatusCoder
+	if errors.As(err, &coder) {
+		return coder.HTTPStatus()
+	}
+	return 0
+}
+
+// PublicMessage returns the message of the first error in err's chain that
+// implements PublicMessager, or "" if there is none
+func PublicMessage(err error) string {
+	var messager PublicMessager
+	if errors.As(err, &messager) {
+		return messager.PublicMessage()
+	}
+	return ""
+}
+
+// responseStatus is the HTTP status written for err: its StatusCoder
+// status if that is an error status, 500 otherwise
+func responseStatus(err error) int {
+	if status := HTTPStatus(err); status >= http.StatusBadRequest {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Definition is a reusable error with a code, HTTP status and public
+// message. Instances created with New get fresh IDs and unwrap to the
+// Definition, so errors.Is(err, ErrQuotaExceeded) works:
+//
+//	var ErrQuotaExceeded = errorid.Define("QUOTA_EXCEEDED", http.StatusTooManyRequests, "quota exceeded")
+//
+//	return ErrQuotaExceeded.New(map[string]interface{}{"limit": 100})
+type Definition struct {
+	code     string
+	status   int
+	message  string
+	severity Severity
+	category string
+	retry    *RetryHint
+	cacheTTL time.Duration
+}
+
+// Define creates an error Definition
+func Define(code string, status int, message string) *Definition {
+	return &Definition{
+		code:    code,
+		status:  status,
+		message: message,
+	}
+}
+
+// WithSeverity sets the severity of d's instances and returns d
+// Meant to be chained on Define when declaring the variable
+func (d *Definition) WithSeverity(s Severity) *Definition {
+	d.severity = s
+	return d
+}
+
+// WithCategory sets the category of d's instances and returns d
+func (d *Definition) WithCategory(category string) *Definition {
+	d.category = category
+	return d
+}
+
+// WithRetry marks d's instances as retryable after the given wait
+// (0 for no suggested wait) and returns d
+func (d *Definition) WithRetry(after time.Duration) *Definition {
+	d.retry = &RetryHint{Retryable: true, After: after}
+	return d
+}
+
+// WithCacheTTL lets clients keep d's error responses (4xx only, as
+// private) for ttl, e.g. for permanent not-found results, and returns d
+func (d *Definition) WithCacheTTL(ttl time.Duration) *Definition {
+	d.cacheTTL = ttl
+	return d
+}
+
+// Error implements error interface
+func (d *Definition) Error() string {
+	return d.message
+}
+
+// ErrorCode implements Coder
+func (d *Definition) ErrorCode() string {
+	return d.code
+}
+
+// HTTPStatus implements StatusCoder
+func (d *Definition) HTTPStatus() int {
+	return d.status
+}
+
+// PublicMessage implements PublicMessager
+func (d *Definition) PublicMessage() string {
+	return d.message
+}
+
+// ErrorSeverity implements SeverityCarrier
+func (d *Definition) ErrorSeverity() Severity {
+	return d.severity
+}
+
+// ErrorCategory implements CategoryCarrier
+func (d *Definition) ErrorCategory() string {
+	return d.category
+}
+
+// RetryHint implements RetryHinter (ok only after WithRetry)
+func (d *Definition) RetryHint() (RetryHint, bool) {
+	if d.retry == nil {
+		return RetryHint{}, false
+	}
+	return *d.retry, true
+}
+
+// CacheTTL implements CacheHinter
+func (d *Definition) CacheTTL() time.Duration {
+	return d.cacheTTL
+}
+
+// New creates an instance of d with a fresh ID using the default handler
+func (d *Definition) New(details map[string]interface{}) *ErrorWithID {
+	return defaultFor(2).wrap(nil, d, "", details)
+}
+
+// NewWith creates an instance of d with a fresh ID using handler h
+func (d *Definition) NewWith(h *Handler, details map[string]interface{}) *ErrorWithID {
+	return h.wrap(nil, d, "", details)
+}
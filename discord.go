@@ -0,0 +1,181 @@
+package errorid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Discord message limits
+const (
+	discordMaxDescription = 4096
+	discordMaxFields      = 25
+	discordMaxFieldValue  = 1024
+	discordErrorColor     = 0xE74C3C
+	discordMaxFiles       = 10
+	discordEmptyField     = "-" // Discord rejects empty field names and values
+)
+
+// DiscordNotifier posts errors to a Discord channel webhook as embeds
+//
+//	discord := &errorid.DiscordNotifier{WebhookURL: os.Getenv("DISCORD_WEBHOOK")}
+//	errorid.Configure(errorid.Config{OnError: discord.OnError, AsyncCallback: true})
+type DiscordNotifier struct {
+	WebhookURL   string
+	Username     string       // Overrides the webhook's name if set
+	MaxPerMinute int          // Messages per minute, default 30 (< 0 = unlimited)
+	HTTPClient   *http.Client // Default http.DefaultClient
+	
+	limiter rateLimiter
+}
+
+// discordMessage is the webhook execute payload
+type discordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Content  string         `json:"content,omitempty"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Timestamp   string         `json:"timestamp"`
+	Fields      []discordField `json:"fields,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// Notify posts err to the webhook
+// Returns ErrRateLimited when MaxPerMinute was reached (never for
+// critical errors)
+func (d *DiscordNotifier) Notify(ctx context.Context, err *ErrorWithID) error {
+	limit := d.MaxPerMinute
+	if limit == 0 {
+		limit = 30
+	}
+	
+	// Critical errors skip the limit without using it up
+	ok, suppressed := true, 0
+	if !isCritical(err) {
+		ok, suppressed = d.limiter.allow(limit, time.Now())
+	}
+	if !ok {
+		return ErrRateLimited
+	}
+	
+	if len(err.Attachments) > 0 {
+		return d.postWithFiles(ctx, d.message(err, suppressed), err.Attachments)
+	}
+	return postJSON(ctx, d.HTTPClient, d.WebhookURL, nil, d.message(err, suppressed))
+}
+
+// postWithFiles posts msg with attachments uploaded as files
+func (d *DiscordNotifier) postWithFiles(ctx context.Context, msg discordMessage, attachments []Attachment) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	form.WriteField("payload_json", string(payload))
+	
+	for i, a := range attachments {
+		if i == discordMaxFiles {
+			break
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[%d]"; filename=%q`, i, a.Name))
+		if a.ContentType != "" {
+			header.Set("Content-Type", a.ContentType)
+		}
+		part, err := form.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		part.Write(a.Data)
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.WebhookURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	
+	return doPost(d.HTTPClient, req)
+}
+
+// OnError is a Config.OnError callback; delivery errors are dropped
+func (d *DiscordNotifier) OnError(err *ErrorWithID) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	
+	d.Notify(ctx, err)
+}
+
+// message formats err as an embed
+func (d *DiscordNotifier) message(err *ErrorWithID, suppressed int) discordMessage {
+	description := fmt.Sprintf("%v", err.Original)
+	if err.Context != "" {
+		description = fmt.Sprintf("**%s**: %v", err.Context, err.Original)
+	}
+	if err.Origin != "" {
+		description += "\n`" + err.Origin + "`"
+	}
+	
+	embed := discordEmbed{
+		Title:       "Error " + err.ID,
+		Description: truncate(description, discordMaxDescription),
+		Color:       discordErrorColor,
+		Timestamp:   time.Unix(err.Timestamp, 0).UTC().Format(time.RFC3339),
+	}
+	
+	keys := make([]string, 0, len(err.Details))
+	for k := range err.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	
+	for _, k := range keys {
+		if len(embed.Fields) == discordMaxFields {
+			break
+		}
+		embed.Fields = append(embed.Fields, discordField{
+			Name:   discordFieldText(k),
+			Value:  discordFieldText(truncate(fmt.Sprint(err.Details[k]), discordMaxFieldValue)),
+			Inline: true,
+		})
+	}
+	
+	msg := discordMessage{
+		Username: d.Username,
+		Embeds:   []discordEmbed{embed},
+	}
+	if suppressed > 0 {
+		msg.Content = fmt.Sprintf("%d more error(s) were not sent (rate limit)", suppressed)
+	}
+	return msg
+}
+
+// discordFieldText returns s, or a placeholder if s is blank
+func discordFieldText(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return discordEmptyField
+	}
+	return s
+}
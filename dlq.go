@@ -0,0 +1,84 @@
+package errorid
+
+import (
+	"strconv"
+)
+
+// Message header keys written by StampHeaders
+// Lowercase, so they survive brokers that normalize header names
+const (
+	HeaderErrorID         = "x-error-id"          // ID of the latest failure
+	HeaderOriginalErrorID = "x-original-error-id" // ID of the first failure
+	HeaderErrorContext    = "x-error-context"     // Context of the latest failure
+	HeaderErrorTimestamp  = "x-error-timestamp"   // Unix seconds of the latest failure
+	HeaderErrorAttempts   = "x-error-attempts"    // Failures stamped so far
+)
+
+// HeaderCarrier is a message's header set (http.Header, MapCarrier, or an
+// adapter for a broker client's headers)
+type HeaderCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// MapCarrier adapts a map[string]string to HeaderCarrier
+type MapCarrier map[string]string
+
+// Get returns the value of key
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set stores value under key
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// StampHeaders records err in the headers of a message being sent to a
+// dead-letter queue. The first failure's ID is kept as the original ID and
+// attempts are counted, so replay tooling can tie every reprocessing
+// attempt back to the support ID the customer was given
+func StampHeaders(headers HeaderCarrier, err *ErrorWithID) {
+	if err == nil {
+		return
+	}
+	
+	if headers.Get(HeaderOriginalErrorID) == "" {
+		original := headers.Get(HeaderErrorID)
+		if original == "" {
+			original = err.ID
+		}
+		headers.Set(HeaderOriginalErrorID, original)
+	}
+	
+	attempts, _ := strconv.Atoi(headers.Get(HeaderErrorAttempts))
+	
+	headers.Set(HeaderErrorID, err.ID)
+	headers.Set(HeaderErrorContext, err.Context)
+	headers.Set(HeaderErrorTimestamp, strconv.FormatInt(err.Timestamp, 10))
+	headers.Set(HeaderErrorAttempts, strconv.Itoa(attempts+1))
+}
+
+// HeaderInfo is what StampHeaders recorded on a message
+type HeaderInfo struct {
+	ErrorID         string
+	OriginalErrorID string
+	Context         string
+	Timestamp       int64
+	Attempts        int
+}
+
+// ReadHeaders returns the error information stamped on a message
+// ok is false if the message was never stamped
+func ReadHeaders(headers HeaderCarrier) (info HeaderInfo, ok bool) {
+	info.ErrorID = headers.Get(HeaderErrorID)
+	if info.ErrorID == "" {
+		return HeaderInfo{}, false
+	}
+	
+	info.OriginalErrorID = headers.Get(HeaderOriginalErrorID)
+	info.Context = headers.Get(HeaderErrorContext)
+	info.Timestamp, _ = strconv.ParseInt(headers.Get(HeaderErrorTimestamp), 10, 64)
+	info.Attempts, _ = strconv.Atoi(headers.Get(HeaderErrorAttempts))
+	return info, true
+}
@@ -0,0 +1,149 @@
+package errorid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultTenantKey is the Details key holding the affected tenant
+// (Config.TenantKey)
+const DefaultTenantKey = "tenant"
+
+// FingerprintIndex is implemented by stores that can list errors by
+// fingerprint (MemoryStore, SQLStore), for FindDuplicates
+type FingerprintIndex interface {
+	// ByFingerprint returns the stored errors with fingerprint wrapped
+	// between since and until (inclusive), oldest first
+	ByFingerprint(ctx context.Context, fingerprint string, since, until time.Time) ([]*ErrorWithID, error)
+}
+
+// DuplicateReport tells support whether the error in a ticket is isolated
+// or part of a wider issue
+type DuplicateReport struct {
+	Error       *ErrorWithID   // The error of the ticket
+	Fingerprint string         // Its fingerprint
+	Tenant      string         // Its tenant ("" if none)
+	SameTenant  []*ErrorWithID // Other occurrences for the same tenant
+	Others      int            // Occurrences for other (or no) tenants
+	Tenants     int            // Distinct other tenants affected
+}
+
+// Isolated reports whether no other occurrence was found in the window
+func (r DuplicateReport) Isolated() bool {
+	return len(r.SameTenant) == 0 && r.Others == 0
+}
+
+// Widespread reports whether other tenants hit the same error
+func (r DuplicateReport) Widespread() bool {
+	return r.Tenants > 0
+}
+
+// FindDuplicates loads the error with id from Config.Store and finds the
+// other errors with its fingerprint wrapped within window before or after
+// it, split by tenant (Details[Config.TenantKey]):
+//
+//	report, err := handler.FindDuplicates(ctx, ticket.ErrorID, time.Hour)
+//	if report.Widespread() {
+//	    // link the ticket to the incident instead of investigating
+//	}
+//
+// Returns ErrNotFound if there is no such error, and an error if the store
+// is not a FingerprintIndex
+func (h *Handler) FindDuplicates(ctx context.Context, id string, window time.Duration) (DuplicateReport, error) {
+	target, err := h.Lookup(ctx, id)
+	if err != nil {
+		return DuplicateReport{}, err
+	}
+	index, ok := h.config.Store.(FingerprintIndex)
+	if !ok {
+		return DuplicateReport{}, fmt.Errorf("errorid: store %T can't list errors by fingerprint", h.config.Store)
+	}
+	
+	key := h.config.TenantKey
+	if key == "" {
+		key = DefaultTenantKey
+	}
+	report := DuplicateReport{
+		Error:       target,
+		Fingerprint: target.Fingerprint(),
+		Tenant:      tenantOf(target, key),
+	}
+	
+	at := time.Unix(target.Timestamp, 0)
+	matches, err := index.ByFingerprint(ctx, report.Fingerprint, at.Add(-window), at.Add(window))
+	if err != nil {
+		return report, err
+	}
+	
+	tenants := make(map[string]bool)
+	for _, match := range matches {
+		if match.ID == target.ID {
+			continue
+		}
+		tenant := tenantOf(match, key)
+		if tenant == report.Tenant && tenant != "" {
+			report.SameTenant = append(report.SameTenant, match)
+			continue
+		}
+		report.Others++
+		if tenant != "" {
+			tenants[tenant] = true
+		}
+	}
+	report.Tenants = len(tenants)
+	return report, nil
+}
+
+// tenantOf returns the tenant of err as a string ("" if none)
+func tenantOf(err *ErrorWithID, key string) string {
+	if v, ok := err.Details[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// ByFingerprint implements FingerprintIndex for the errors still held
+func (s *MemoryStore) ByFingerprint(ctx context.Context, fingerprint string, since, until time.Time) ([]*ErrorWithID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var matches []*ErrorWithID
+	for _, id := range s.order {
+		err := s.errors[id]
+		if err.Timestamp < since.Unix() || err.Timestamp > until.Unix() {
+			continue
+		}
+		if err.Fingerprint() == fingerprint {
+			matches = append(matches, err)
+		}
+	}
+	return matches, nil
+}
+
+// ByFingerprint implements FingerprintIndex by scanning the errors stored
+// in the time range
+func (s *SQLStore) ByFingerprint(ctx context.Context, fingerprint string, since, until time.Time) ([]*ErrorWithID, error) {
+	rows, err := s.DB.QueryContext(ctx, s.query("SELECT data FROM %s WHERE timestamp >= ? AND timestamp <= ? ORDER BY timestamp"), since.Unix(), until.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var matches []*ErrorWithID
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var record bundleError
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			continue
+		}
+		if record.Fingerprint == fingerprint {
+			matches = append(matches, record.errorWithID())
+		}
+	}
+	return matches, rows.Err()
+}
@@ -0,0 +1,30 @@
+package errorid
+
+import (
+	"os"
+	"strings"
+)
+
+// envDetails returns defaults with the allowlisted environment variables
+// added under their lowercased names ("REGION" -> "region"). Unset and
+// empty variables are left out, and explicit defaults keep precedence
+// defaults itself is not modified
+func envDetails(defaults map[string]interface{}, names []string, getenv func(string) string) map[string]interface{} {
+	details := make(map[string]interface{}, len(defaults)+len(names))
+	for _, name := range names {
+		if value := getenv(name); value != "" {
+			details[strings.ToLower(name)] = value
+		}
+	}
+	for k, v := range defaults {
+		details[k] = v
+	}
+	return details
+}
+
+// applyEnvDetails adds Config.EnvDetails to DefaultDetails, once at New
+func (h *Handler) applyEnvDetails() {
+	if len(h.config.EnvDetails) > 0 {
+		h.config.DefaultDetails = envDetails(h.config.DefaultDetails, h.config.EnvDetails, os.Getenv)
+	}
+}
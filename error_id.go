@@ -1,9 +1,14 @@
 package errorid
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 )
 
 // ErrorWithID wraps an error with a unique tracking ID
@@ -12,64 +17,364 @@ type ErrorWithID struct {
 	Original     error                  // Original error
 	Context      string                 // Context where error occurred
 	StackTrace   string                 // Stack trace (if enabled)
+	Origin       string                 // Wrap site as "file:line function" (if enabled)
 	Details      map[string]interface{} // Additional metadata
 	Timestamp    int64                  // Unix timestamp when error was wrapped
+	Related      []string               // IDs of errors wrapped earlier in the same request
+	PanicStack   string                 // Stack of the panicking goroutine at the panic site (recovered panics only)
+	IncidentID   string                 // Shared by errors of the same burst (Config.IncidentThreshold)
+	Build        *BuildInfo             // Build that wrapped the error (nil if unknown or disabled)
+	Component    string                 // Subsystem of the wrapping handler (Config.Component)
+	Severity     Severity               // From the error chain (SeverityCarrier), default SeverityError
+	Category     string                 // From the error chain (CategoryCarrier), if any
+	Attachments  []Attachment           // From the error chain (WithAttachment), if any
+	Causes       map[string]error       // Failures by dependency name, from the error chain (WithCauses)
+	Contexts     []string               // Later contexts of the same failure in the request (DoubleReportGuard)
+	Correlation  map[string]string      // Request headers echoed in responses (Config.EchoHeaders)
+	Breadcrumbs  []Breadcrumb           // Recorded in the request before the error (AddBreadcrumb)
+	Request      *CapturedRequest       // Request that failed (Config.CaptureRequests, development only)
+	Trail        []TrailEntry           // Layers that wrapped the error, innermost first (Config.RecordTrail)
+	Goroutine    uint64                 // ID of the wrapping goroutine (Config.AnnotateTrace)
+	
+	format *template.Template // Config.ErrorFormat of the wrapping handler
+	policy *Policy            // Route policy of the wrap context (WithPolicy)
+	flags  *Flags             // From Config.Flags, if any were set
+	label  *ContextLabel      // Declared context the error was wrapped with
+	
+	fingerprint string // Stored fingerprint of a loaded error
 }
 
 // Error implements error interface
 func (e *ErrorWithID) Error() string {
+	if e.format != nil {
+		var b strings.Builder
+		if err := e.format.Execute(&b, (*errorView)(e)); err == nil {
+			return b.String()
+		}
+		// Fall back to the default layout on template errors
+	}
+	
+	if e.ID == "" {
+		// IDNone
+		if e.Context != "" {
+			return fmt.Sprintf("%s: %v", e.Context, e.Original)
+		}
+		return fmt.Sprint(e.Original)
+	}
+	
 	if e.Context != "" {
 		return fmt.Sprintf("[%s] %s: %v", e.ID, e.Context, e.Original)
 	}
 	return fmt.Sprintf("[%s] %v", e.ID, e.Original)
 }
 
+// errorView is the ErrorFormat data: the error's fields without its
+// methods, so "{{.}}" or "{{.Error}}" can't call Error recursively
+type errorView ErrorWithID
+
 // Unwrap returns the original error for errors.Is and errors.As
 func (e *ErrorWithID) Unwrap() error {
 	return e.Original
 }
 
+// ID is an errors.Is target matching the wrapped error with that ID:
+//
+//	errors.Is(err, errorid.ID("ERR-20250101-abc123"))
+type ID string
+
+// Error implements error interface
+func (id ID) Error() string {
+	return "error " + string(id)
+}
+
+// AnyWrapped is an errors.Is target matching any error wrapped by this
+// package, wherever it sits in the chain
+var AnyWrapped = errors.New("errorid: any wrapped error")
+
+// Is lets errors.Is match e by ID (see ID) or with AnyWrapped
+func (e *ErrorWithID) Is(target error) bool {
+	if id, ok := target.(ID); ok {
+		return e.ID != "" && e.ID == string(id)
+	}
+	return target == AnyWrapped
+}
+
 var (
 	defaultHandler = New(DefaultConfig())  // Direct initialization like stdlib
 	configureMu    sync.Mutex
 	configured     bool
+	configuredAt   string      // file:line of the first Configure call
+	locked         atomic.Bool // set by the first use of the default handler
+	lockedAt       string      // file:line of that first use (e.g. a Wrap call)
+)
+
+// Errors returned by ConfigureE and TryConfigure
+var (
+	ErrAlreadyConfigured = errors.New("errorid: Configure() called multiple times")
+	ErrConfigLocked      = errors.New("errorid: default handler already in use")
+	ErrInvalidConfig     = errors.New("errorid: invalid config")
 )
 
 // Configure sets up the global default handler with custom config
-// Must be called at program startup, before any Wrap() calls
+// Must be called at program startup, before any Wrap() calls: handlers
+// obtained earlier (e.g. with Default) keep the previous config
 // Can only be called once - subsequent calls will panic
+// The panic message names the call sites of the first Configure and
+// the first use of the default handler, so conflicting setups are easy
+// to track down. Use ConfigureE to get an error instead of a panic
 func Configure(cfg Config) {
+	if err := configure(cfg, callerSite(2), false); err != nil {
+		panic(err)
+	}
+}
+
+// ConfigureE is like Configure but returns an error instead of panicking
+// Errors wrap ErrAlreadyConfigured or ErrInvalidConfig
+func ConfigureE(cfg Config) error {
+	return configure(cfg, callerSite(2), false)
+}
+
+// TryConfigure is a strict ConfigureE: it also fails, with ErrConfigLocked,
+// once the default handler was used (Wrap, Default, RecoveryMiddleware,
+// ...), as that code ran with the previous config. Errors name the call
+// sites of the first Configure and the first use
+func TryConfigure(cfg Config) error {
+	return configure(cfg, callerSite(2), true)
+}
+
+// configure validates cfg and installs it as the default handler
+// site is the file:line of the Configure/ConfigureE/TryConfigure caller;
+// strict rejects configuring a default handler already in use
+func configure(cfg Config, site string, strict bool) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	
 	configureMu.Lock()
 	defer configureMu.Unlock()
 	
 	if configured {
-		panic("errorid: Configure() called multiple times. Call Configure() only once at program startup.")
+		return fmt.Errorf("%w (first call at %s, this call at %s%s); call Configure() only once at program startup", ErrAlreadyConfigured, configuredAt, site, firstUse())
+	}
+	
+	if strict && locked.Load() {
+		return fmt.Errorf("%w since %s, Configure() called at %s; call Configure() before using the default handler", ErrConfigLocked, lockedAt, site)
 	}
 	
 	defaultHandler = New(cfg)
 	configured = true
+	configuredAt = site
+	return nil
+}
+
+// firstUse describes the first use of the default handler for Configure
+// errors, or "" if it wasn't used
+func firstUse() string {
+	if !locked.Load() {
+		return ""
+	}
+	return ", default handler first used at " + lockedAt
+}
+
+// defaultFor returns the default handler, marking it as in use: every
+// singleton function gets it here, so TryConfigure can tell it was used
+// skip is the number of frames above defaultFor to record as the use site
+func defaultFor(skip int) *Handler {
+	lockConfig(skip + 1)
+	return defaultHandler
+}
+
+// lockConfig marks the default config as in use so later TryConfigure calls
+// fail. Only the first call records its site; afterwards this is a single
+// atomic load
+func lockConfig(skip int) {
+	if locked.Load() {
+		return
+	}
+	
+	site := callerSite(skip + 1)
+	
+	configureMu.Lock()
+	defer configureMu.Unlock()
+	
+	if !locked.Load() {
+		lockedAt = site
+		locked.Store(true)
+	}
 }
 
 // Wrap wraps an error with a unique ID using the default handler
 // This is the main singleton API for simple use cases
 func Wrap(err error, context string) *ErrorWithID {
-	return defaultHandler.Wrap(err, context)
+	return defaultFor(2).Wrap(err, context)
+}
+
+// Newf creates a new error with an ID from a format string
+func Newf(format string, args ...interface{}) *ErrorWithID {
+	return defaultFor(2).Newf(format, args...)
 }
 
 // WrapWithDetails wraps error with additional metadata
 func WrapWithDetails(err error, context string, details map[string]interface{}) *ErrorWithID {
-	return defaultHandler.WrapWithDetails(err, context, details)
+	return defaultFor(2).WrapWithDetails(err, context, details)
 }
 
 // Default returns the singleton handler instance
 // Useful for accessing handler methods directly
 func Default() *Handler {
-	return defaultHandler
+	return defaultFor(2)
+}
+
+// parseErrorFormat parses a Config.ErrorFormat template
+func parseErrorFormat(format string) (*template.Template, error) {
+	return template.New("errorid").Option("missingkey=zero").Parse(format)
+}
+
+// packagePrefix is the function name prefix of this package's own frames
+var packagePrefix = func() string {
+	name := runtime.FuncForPC(reflect.ValueOf(New).Pointer()).Name()
+	return name[:strings.LastIndex(name, ".")+1]
+}()
+
+// captureOrigin returns "file:line function" of the wrap site
+// Much cheaper than a full stack: only a few frames are symbolized
+func captureOrigin(skip int) string {
+	frames := wrapSiteFrames(skip, 1)
+	if len(frames) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d %s", frames[0].File, frames[0].Line, frames[0].Function)
+}
+
+// inferContext names the wrap site's function for errors wrapped without
+// a context (Config.InferContext). Runtime frames are passed over, so
+// recovered panics are named after the function that panicked
+func inferContext(skip int) string {
+	for _, frame := range wrapSiteFrames(skip, 4) {
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			return functionContext(frame.Function)
+		}
+	}
+	return ""
+}
+
+// functionContext shortens a function name to package.Type.Method, the
+// package being the last element of its import path, e.g.
+// "github.com/acme/shop/orders.(*Service).Place.func1" to
+// "orders.Service.Place"
+func functionContext(function string) string {
+	name := function[strings.LastIndex(function, "/")+1:]
+	name = strings.NewReplacer("(*", "", ")", "", "[...]", "").Replace(name)
+	
+	parts := strings.Split(name, ".")
+	for len(parts) > 2 && isClosureName(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ".")
 }
 
-// captureStackTrace captures current stack trace
+// isClosureName reports whether part names a compiler-generated closure
+// ("func1", "gowrap2", or "3" for a nested one)
+func isClosureName(part string) bool {
+	part = strings.TrimPrefix(strings.TrimPrefix(part, "func"), "gowrap")
+	if part == "" {
+		return false
+	}
+	for _, r := range part {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// wrapSiteFrames returns up to max frames starting at the wrap site: the
+// first frame outside this package (the code that called Wrap, Newf, ...),
+// moved up by skip more frames for callers using their own wrap helpers
+func wrapSiteFrames(skip, max int) []runtime.Frame {
+	pcs := make([]uintptr, 16+skip+max)
+	n := runtime.Callers(3, pcs) // skip runtime.Callers, this function and its caller
+	frames := runtime.CallersFrames(pcs[:n])
+	
+	var result []runtime.Frame
+	inPackage := true
+	for {
+		frame, more := frames.Next()
+		if inPackage && !isPackageFrame(frame) {
+			inPackage = false
+		}
+		
+		if !inPackage {
+			if skip > 0 {
+				skip--
+			} else {
+				result = append(result, frame)
+				if len(result) == max {
+					return result
+				}
+			}
+		}
+		
+		if !more {
+			return result
+		}
+	}
+}
+
+// isPackageFrame reports whether frame belongs to this package's code
+// Frames from this package's tests count as caller code
+func isPackageFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, packagePrefix) && !strings.HasSuffix(frame.File, "_test.go")
+}
+
+// maxStackFrames limits the depth of captured stack traces
+const maxStackFrames = 64
+
+// captureStackTrace captures the stack starting at the wrap site
+// skip moves the top of the stack up, like in wrapSiteFrames
 func captureStackTrace(skip int) string {
-	buf := make([]byte, 4096)
-	n := runtime.Stack(buf, false)
-	return string(buf[:n])
+	var b strings.Builder
+	for _, frame := range wrapSiteFrames(skip, maxStackFrames) {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return b.String()
+}
+
+// capturePanicStack captures the stack of the panic being recovered,
+// starting at the frame that panicked. Must be called from the deferred
+// function that calls recover. Returns "" when no panic is in progress
+func capturePanicStack() string {
+	pcs := make([]uintptr, 32+maxStackFrames)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	
+	var b strings.Builder
+	panicking, count := false, 0
+	for {
+		frame, more := frames.Next()
+		
+		switch {
+		case frame.Function == "runtime.gopanic":
+			panicking = true
+		case !panicking, count == maxStackFrames:
+		case count == 0 && strings.HasPrefix(frame.Function, "runtime."):
+			// runtime.panicmem, runtime.sigpanic, ... of runtime errors
+		default:
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+			count++
+		}
+		
+		if !more {
+			return b.String()
+		}
+	}
+}
+
+// callerSite returns "file:line" of the caller skip frames above callerSite
+func callerSite(skip int) string {
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
 }
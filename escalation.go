@@ -0,0 +1,112 @@
+package errorid
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// escalationSweepSize is the number of tracked fingerprints above which
+// quiet ones are pruned
+const escalationSweepSize = 1024
+
+// EscalationRule sets the severity of errors whose fingerprint occurred
+// at least Count times within Per (default 1 minute), including this one
+type EscalationRule struct {
+	Count    int
+	Per      time.Duration
+	Severity Severity
+}
+
+// Escalate returns an Interceptor setting each error's severity from how
+// often its Fingerprint occurs. The matching rule with the highest
+// severity wins; errors whose chain sets a severity (SeverityCarrier) are
+// only ever raised. Put it before Sample and notifier routing depends on
+// the result, so frequent errors page without changes at call sites:
+//
+//	errorid.Escalate(
+//	    errorid.EscalationRule{Count: 1, Severity: errorid.SeverityWarning},
+//	    errorid.EscalationRule{Count: 100, Per: time.Minute, Severity: errorid.SeverityCritical},
+//	)
+func Escalate(rules ...EscalationRule) Interceptor {
+	e := &escalation{rules: rules, seen: make(map[string][]time.Time)}
+	for i := range e.rules {
+		if e.rules[i].Per <= 0 {
+			e.rules[i].Per = time.Minute
+		}
+		if e.rules[i].Per > e.window {
+			e.window = e.rules[i].Per
+		}
+		if e.rules[i].Count > e.keep {
+			e.keep = e.rules[i].Count
+		}
+	}
+	
+	return func(next WrapFunc) WrapFunc {
+		return func(err *ErrorWithID) *ErrorWithID {
+			if severity, ok := e.observe(err.Fingerprint(), time.Now()); ok {
+				var carrier SeverityCarrier
+				explicit := errors.As(err.Original, &carrier) && carrier.ErrorSeverity() != 0
+				if !explicit || severity > err.Severity {
+					err.Severity = severity
+				}
+			}
+			return next(err)
+		}
+	}
+}
+
+// escalation keeps recent occurrence times per fingerprint
+type escalation struct {
+	mu     sync.Mutex
+	rules  []EscalationRule
+	window time.Duration // longest Per
+	keep   int           // largest Count: older occurrences never matter
+	seen   map[string][]time.Time
+}
+
+// observe records an occurrence and returns the severity of the highest
+// matching rule, ok false if none matches
+func (e *escalation) observe(fingerprint string, now time.Time) (severity Severity, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	
+	times, known := e.seen[fingerprint]
+	if !known && len(e.seen) >= escalationSweepSize {
+		e.sweep(now)
+	}
+	
+	// Keep the latest occurrences inside the longest window, newest last
+	cutoff := now.Add(-e.window)
+	kept := times[:0]
+	for _, at := range times {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	kept = append(kept, now)
+	if len(kept) > e.keep {
+		kept = kept[len(kept)-e.keep:]
+	}
+	e.seen[fingerprint] = kept
+	
+	for _, rule := range e.rules {
+		if rule.Count <= 0 || rule.Count > len(kept) {
+			continue
+		}
+		// The Count-th most recent occurrence must be within Per
+		if now.Sub(kept[len(kept)-rule.Count]) <= rule.Per && (!ok || rule.Severity > severity) {
+			severity, ok = rule.Severity, true
+		}
+	}
+	return severity, ok
+}
+
+// sweep drops fingerprints not seen within the longest window
+func (e *escalation) sweep(now time.Time) {
+	for fingerprint, times := range e.seen {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) > e.window {
+			delete(e.seen, fingerprint)
+		}
+	}
+}
@@ -10,7 +10,8 @@ import (
 )
 
 func main() {
-	fmt.Println("=== Advanced Error ID Example ===\n")
+	fmt.Println("=== Advanced Error ID Example ===")
+	fmt.Println()
 	
 	// Example 1: Configure global singleton
 	configureGlobalHandler()
@@ -8,7 +8,8 @@ import (
 )
 
 func main() {
-	fmt.Println("=== Simple Error ID Example ===\n")
+	fmt.Println("=== Simple Error ID Example ===")
+	fmt.Println()
 	
 	// Example 1: Basic usage with default singleton
 	err := doSomething()
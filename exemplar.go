@@ -0,0 +1,28 @@
+package errorid
+
+import "unicode/utf8"
+
+// maxExemplarRunes is the OpenMetrics limit on the combined length of an
+// exemplar's label names and values
+const maxExemplarRunes = 128
+
+// ExemplarLabels returns exemplar labels linking a metric sample to err:
+// "error_id", plus "incident_id" when set and within the OpenMetrics
+// 128-rune limit. The map converts directly to prometheus.Labels:
+//
+//	counter.WithLabelValues(err.Category).(prometheus.ExemplarAdder).
+//	    AddWithExemplar(1, errorid.ExemplarLabels(err))
+//
+// Returns nil for nil errors and errors without an ID (IDNone)
+func ExemplarLabels(err *ErrorWithID) map[string]string {
+	if err == nil || err.ID == "" {
+		return nil
+	}
+	
+	labels := map[string]string{"error_id": err.ID}
+	size := utf8.RuneCountInString("error_id") + utf8.RuneCountInString(err.ID)
+	if err.IncidentID != "" && size+utf8.RuneCountInString("incident_id")+utf8.RuneCountInString(err.IncidentID) <= maxExemplarRunes {
+		labels["incident_id"] = err.IncidentID
+	}
+	return labels
+}
@@ -0,0 +1,98 @@
+package errorid
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// ResponseExtension renders Details keys as extra top-level fields of
+// error responses, for errors with a Code and/or Category (empty fields
+// match anything):
+//
+//	errorid.ResponseExtension{Code: "VALIDATION", Fields: []string{"fields"}}
+//	errorid.ResponseExtension{Category: "quota", Fields: []string{"limit", "reset_at"}}
+//
+// The fields are part of the public contract, so they are sent at every
+// ResponseDetail level. Keys clashing with standard fields are skipped
+type ResponseExtension struct {
+	Code     string
+	Category string
+	Fields   []string
+}
+
+// matches reports whether x applies to err
+func (x ResponseExtension) matches(err *ErrorWithID) bool {
+	return (x.Code == "" || x.Code == ErrorCode(err)) &&
+		(x.Category == "" || x.Category == err.Category)
+}
+
+// responseExtensions collects the extension fields of err from its Details
+func (h *Handler) responseExtensions(err *ErrorWithID) map[string]interface{} {
+	var fields map[string]interface{}
+	for _, x := range h.config.ResponseExtensions {
+		if !x.matches(err) {
+			continue
+		}
+		for _, key := range x.Fields {
+			value, ok := err.Details[key]
+			if !ok {
+				continue
+			}
+			if fields == nil {
+				fields = make(map[string]interface{})
+			}
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// errorResponseJSON has ErrorResponse's fields without its MarshalJSON
+type errorResponseJSON ErrorResponse
+
+// MarshalJSON encodes r with its Extensions as top-level fields
+func (r ErrorResponse) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(errorResponseJSON(r))
+	if err != nil || len(r.Extensions) == 0 {
+		return data, err
+	}
+	
+	var standard map[string]json.RawMessage
+	if err := json.Unmarshal(data, &standard); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(r.Extensions))
+	for key := range r.Extensions {
+		if _, clash := standard[key]; !clash && !standardResponseField[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	
+	var b bytes.Buffer
+	b.Write(data[:len(data)-1])
+	for _, key := range keys {
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(r.Extensions[key])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteByte(',')
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// standardResponseField are ErrorResponse JSON names, including omitted
+// ones, so extensions never change the meaning of a standard field
+var standardResponseField = map[string]bool{
+	"error_id": true, "message": true, "code": true, "incident_id": true,
+	"retryable": true, "retry_after_seconds": true, "timestamp": true,
+	"time": true, "details": true, "stack_trace": true, "panic_stack": true,
+	"stack_frames": true, "panic_frames": true, "related_error_ids": true,
+	"causes": true, "correlation": true,
+}
@@ -0,0 +1,393 @@
+package errorid
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFailoverRetry is how often a degraded FailoverStore retries the
+// store when FailoverStore.RetryInterval is unset
+const DefaultFailoverRetry = 10 * time.Second
+
+// FailoverStore keeps error capture going through a store outage: when a
+// write to the store fails it turns degraded, buffers errors in an
+// in-memory ring of the latest size errors (and SpoolDir, if set) without
+// touching the store, and retries every RetryInterval. Once the store
+// accepts writes again the buffer is backfilled oldest first and it
+// recovers
+//
+//	store := errorid.NewFailoverStore(sqlStore, 10000)
+//	store.SpoolDir = "/var/lib/myapp/errorid-failover"
+//	store.Backfill(ctx) // errors spooled by an earlier process
+//	errorid.Configure(errorid.Config{Store: store})
+//	defer errorid.Default().Close(ctx) // also closes store
+//
+// Save never fails once an error is buffered. While degraded, Handler.Stats
+// reports StoreDegraded and StoreBuffered. Close also closes the wrapped
+// store (see FailoverStore.Close)
+type FailoverStore struct {
+	store    Store
+	size     int
+	mu       sync.Mutex
+	buffer   map[string]*ErrorWithID
+	order    []string // buffered IDs, oldest first
+	degraded bool
+	since    time.Time
+	cause    error
+	dropped  uint64
+	stop     chan struct{}
+	done     chan struct{} // closed when the retry loop exits
+	
+	closeOnce sync.Once
+	closeErr  error
+	
+	// SpoolDir, if set, also writes buffered errors to disk until they are
+	// backfilled, so errors evicted from the ring or left by a crash survive
+	SpoolDir string
+	
+	// RetryInterval is how often a degraded store is retried (default
+	// DefaultFailoverRetry)
+	RetryInterval time.Duration
+	
+	// OnStateChange is called when the store turns degraded, with the
+	// write error, and when it recovers, with nil
+	OnStateChange func(degraded bool, cause error)
+	
+	// OnError is called with spool failures and errors dropped from a full
+	// ring without SpoolDir
+	OnError func(err *ErrorWithID, cause error)
+}
+
+// FailoverStatus is the state of a FailoverStore
+type FailoverStatus struct {
+	Degraded bool      `json:"degraded"`
+	Since    time.Time `json:"since"`           // When it turned degraded
+	Cause    string    `json:"cause,omitempty"` // The write error that degraded it
+	Buffered int       `json:"buffered"`        // Errors waiting for backfill in memory
+	Dropped  uint64    `json:"dropped"`         // Errors evicted from a full ring without SpoolDir
+}
+
+// degradable is implemented by stores with a fallback mode (FailoverStore)
+type degradable interface {
+	Status() FailoverStatus
+}
+
+// NewFailoverStore returns a FailoverStore buffering up to size errors
+// while store is unavailable
+func NewFailoverStore(store Store, size int) *FailoverStore {
+	if size <= 0 {
+		size = 1
+	}
+	return &FailoverStore{
+		store:  store,
+		size:   size,
+		buffer: make(map[string]*ErrorWithID),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Save implements Store: err goes to the store, or to the buffer while
+// degraded or if the write fails
+func (s *FailoverStore) Save(ctx context.Context, err *ErrorWithID) error {
+	s.mu.Lock()
+	degraded := s.degraded
+	s.mu.Unlock()
+	
+	if !degraded {
+		saveErr := s.store.Save(ctx, err)
+		if saveErr == nil {
+			return nil
+		}
+		s.degrade(saveErr)
+	}
+	s.bufferError(err)
+	return nil
+}
+
+// Load implements Store, checking the buffer and SpoolDir first
+func (s *FailoverStore) Load(ctx context.Context, id string) (*ErrorWithID, error) {
+	s.mu.Lock()
+	err, ok := s.buffer[id]
+	s.mu.Unlock()
+	if ok {
+		return err, nil
+	}
+	
+	if file := spoolFile(s.SpoolDir, &ErrorWithID{ID: id}); file != "" {
+		if record, readErr := readSpooled(file); readErr == nil {
+			return record.errorWithID(), nil
+		}
+	}
+	return s.store.Load(ctx, id)
+}
+
+// Status returns whether the store is degraded and how much is buffered
+func (s *FailoverStore) Status() FailoverStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	status := FailoverStatus{
+		Degraded: s.degraded,
+		Buffered: len(s.order),
+		Dropped:  s.dropped,
+	}
+	if s.degraded {
+		status.Since = s.since
+		status.Cause = s.cause.Error()
+	}
+	return status
+}
+
+// degrade switches to the buffer and starts retrying the store
+// After Close it does nothing, so every Save still tries the store
+func (s *FailoverStore) degrade(cause error) {
+	s.mu.Lock()
+	if s.degraded || s.closing() {
+		s.mu.Unlock()
+		return
+	}
+	s.degraded = true
+	s.since = time.Now()
+	s.cause = cause
+	if s.done == nil {
+		s.done = make(chan struct{})
+		go s.retry()
+	}
+	s.mu.Unlock()
+	
+	if s.OnStateChange != nil {
+		s.OnStateChange(true, cause)
+	}
+}
+
+// bufferError adds err to the ring and SpoolDir, evicting the oldest
+// error when the ring is full
+func (s *FailoverStore) bufferError(err *ErrorWithID) {
+	if spoolErr := spoolWrite(s.SpoolDir, err); spoolErr != nil && s.OnError != nil {
+		s.OnError(err, fmt.Errorf("spool: %w", spoolErr))
+	}
+	
+	s.mu.Lock()
+	var evicted *ErrorWithID
+	if _, ok := s.buffer[err.ID]; !ok {
+		if len(s.order) == s.size {
+			evicted = s.buffer[s.order[0]]
+			delete(s.buffer, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.order = append(s.order, err.ID)
+	}
+	s.buffer[err.ID] = err
+	
+	// Evicted errors are still backfilled from SpoolDir
+	if evicted != nil && s.SpoolDir == "" {
+		s.dropped++
+	} else {
+		evicted = nil
+	}
+	s.mu.Unlock()
+	
+	if evicted != nil && s.OnError != nil {
+		s.OnError(evicted, fmt.Errorf("errorid: failover buffer full (%d errors)", s.size))
+	}
+}
+
+// retry backfills every RetryInterval until the store recovers or Close
+func (s *FailoverStore) retry() {
+	interval := s.RetryInterval
+	if interval <= 0 {
+		interval = DefaultFailoverRetry
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	
+	for {
+		select {
+		case <-s.stop:
+			s.exit()
+			return
+		case <-ticker.C:
+		}
+	
+		if _, err := s.Backfill(ctx); err == nil {
+			s.mu.Lock()
+			if !s.degraded {
+				// Recovered; the next failure starts a new loop
+				close(s.done)
+				s.done = nil
+				s.mu.Unlock()
+				return
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// exit marks the retry loop stopped by Close
+func (s *FailoverStore) exit() {
+	s.mu.Lock()
+	close(s.done)
+	s.mu.Unlock()
+}
+
+// Backfill writes buffered and spooled errors to the store, oldest first,
+// and recovers from degraded mode once none are left. It stops at the
+// first failed write, returning how many errors were written. Call it at
+// startup to write errors spooled by an earlier process; while degraded
+// it runs every RetryInterval
+func (s *FailoverStore) Backfill(ctx context.Context) (int, error) {
+	written := 0
+	for {
+		for {
+			s.mu.Lock()
+			if len(s.order) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			err := s.buffer[s.order[0]]
+			s.mu.Unlock()
+	
+			if saveErr := s.store.Save(ctx, err); saveErr != nil {
+				return written, saveErr
+			}
+			spoolRemove(s.SpoolDir, err)
+			s.unbuffer(err)
+			written++
+		}
+	
+		n, spoolErr := s.backfillSpool(ctx)
+		written += n
+		if spoolErr != nil {
+			return written, spoolErr
+		}
+	
+		// Errors buffered meanwhile go first
+		s.mu.Lock()
+		if len(s.order) > 0 {
+			s.mu.Unlock()
+			continue
+		}
+		recovered := s.degraded
+		s.degraded = false
+		s.mu.Unlock()
+	
+		if recovered && s.OnStateChange != nil {
+			s.OnStateChange(false, nil)
+		}
+		return written, nil
+	}
+}
+
+// unbuffer removes err from the ring unless it was replaced meanwhile
+func (s *FailoverStore) unbuffer(err *ErrorWithID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.buffer[err.ID] != err {
+		return
+	}
+	delete(s.buffer, err.ID)
+	for i, id := range s.order {
+		if id == err.ID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// backfillSpool writes spooled errors that are not in the ring (evicted,
+// or left by an earlier process) to the store. Unreadable files are deleted
+func (s *FailoverStore) backfillSpool(ctx context.Context) (int, error) {
+	if s.SpoolDir == "" {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(s.SpoolDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	
+	written := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+	
+		file := filepath.Join(s.SpoolDir, entry.Name())
+		record, readErr := readSpooled(file)
+		if readErr != nil {
+			os.Remove(file)
+			continue
+		}
+	
+		s.mu.Lock()
+		_, buffered := s.buffer[record.ErrorID]
+		s.mu.Unlock()
+		if buffered {
+			continue
+		}
+	
+		if saveErr := s.store.Save(ctx, record.errorWithID()); saveErr != nil {
+			return written, saveErr
+		}
+		os.Remove(file)
+		written++
+	}
+	return written, nil
+}
+
+// closing reports whether Close was called
+func (s *FailoverStore) closing() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops retrying the store and then closes it if it has a Close
+// method, or returns when ctx ends. Errors still buffered in memory are
+// lost; spooled ones are backfilled on next start. Later failed writes are
+// buffered without degrading
+func (s *FailoverStore) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closing() {
+		close(s.stop)
+	}
+	done := s.done
+	s.mu.Unlock()
+	
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	
+	s.closeOnce.Do(func() {
+		s.closeErr = closeStore(ctx, s.store)
+	})
+	return s.closeErr
+}
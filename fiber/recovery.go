@@ -0,0 +1,194 @@
+// Package erroridfiber adapts errorid to Fiber, which runs on fasthttp
+// rather than net/http: Recovery recovers panics in handlers and
+// ErrorHandler answers errors returned by them, both with an error ID and
+// the JSON error response written straight to the fasthttp response
+package erroridfiber
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// ContextKey is the fiber.Ctx local holding the error ID of a recovered
+// panic or a handled error
+const ContextKey = "error_id"
+
+// Recovery returns Fiber middleware doing what errorid's
+// RecoveryMiddleware does for net/http: requests get a Collector (and
+// correlation headers, flood tracking, capture as configured) in their
+// user context, and a panic is wrapped with an error ID, stored under
+// ContextKey, and answered with the JSON error response. Fiber buffers
+// responses, so whatever the handler wrote before panicking is discarded
+// Use it instead of Fiber's recover middleware:
+//
+//	app := fiber.New(fiber.Config{ErrorHandler: erroridfiber.ErrorHandler(handler)})
+//	app.Use(erroridfiber.Recovery(handler))
+//
+// Handlers read the user context for WrapContext and friends:
+//
+//	handler.WrapContext(c.UserContext(), err, "load cart")
+//
+// If h is nil, the default errorid handler is used
+func Recovery(h *errorid.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		handler := h
+		if handler == nil {
+			handler = errorid.Default()
+		}
+		
+		start := time.Now()
+		r := handler.PrepareRequest(request(c))
+		c.SetUserContext(r.Context())
+		
+		defer func() {
+			if rec := recover(); rec != nil {
+				wrapped := handler.RecoverRequest(r, rec, start, progress{c})
+				c.Locals(ContextKey, wrapped.ID)
+				c.Response().ResetBody()
+				handler.WriteErrorRequest(newResponseWriter(c), r, wrapped)
+				err = nil
+			}
+		}()
+		
+		return c.Next()
+	}
+}
+
+// ErrorHandler returns a Fiber error handler for fiber.Config.ErrorHandler
+// Errors that already have an ID are written as they are; others are
+// wrapped with h first, keeping the status of a 5xx *fiber.Error. Client
+// errors from Fiber itself (404 for unknown routes, 405, ...) are routine
+// and get fiber.DefaultErrorHandler's response, without an ID or a report
+// The error ID is stored under ContextKey
+// If h is nil, the default errorid handler is used
+func ErrorHandler(h *errorid.Handler) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		handler := h
+		if handler == nil {
+			handler = errorid.Default()
+		}
+		
+		r := request(c)
+		var wrapped *errorid.ErrorWithID
+		if !errors.As(err, &wrapped) {
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				if fiberErr.Code < http.StatusInternalServerError {
+					return fiber.DefaultErrorHandler(c, err)
+				}
+				err = &statusError{err: err, status: fiberErr.Code}
+			}
+			wrapped = handler.WrapWithDetailsContext(r.Context(), err, "fiber handler failed", map[string]interface{}{
+				"method": r.Method,
+				"path":   r.URL.Path,
+			})
+		}
+		
+		c.Locals(ContextKey, wrapped.ID)
+		c.Response().ResetBody()
+		handler.WriteErrorRequest(newResponseWriter(c), r, wrapped)
+		return nil
+	}
+}
+
+// ErrorID returns the error ID Recovery or ErrorHandler stored in c, or ""
+func ErrorID(c *fiber.Ctx) string {
+	id, _ := c.Locals(ContextKey).(string)
+	return id
+}
+
+// request builds the net/http view of c's request that errorid works
+// with, in c's user context. Strings are copied: fasthttp reuses its
+// buffers once the request ends, and errors outlive it
+func request(c *fiber.Ctx) *http.Request {
+	fr := c.Request()
+	r, err := http.NewRequestWithContext(c.UserContext(), string(fr.Header.Method()), string(fr.RequestURI()), nil)
+	if err != nil {
+		// Unparseable URI: keep the method and the raw path
+		r, _ = http.NewRequestWithContext(c.UserContext(), string(fr.Header.Method()), "/", nil)
+		r.URL.Path = string(fr.URI().PathOriginal())
+	}
+	
+	fr.Header.VisitAll(func(key, value []byte) {
+		r.Header.Add(string(key), string(value))
+	})
+	r.Host = string(fr.Host())
+	r.RemoteAddr = c.Context().RemoteAddr().String()
+	
+	// Read during the request only (request capture), so not copied
+	if body := fr.Body(); len(body) > 0 {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+	}
+	return r
+}
+
+// progress reports the response a handler had buffered when it panicked
+// Nothing reaches the client before the handler returns, so it never
+// counts as written
+type progress struct {
+	c *fiber.Ctx
+}
+
+func (p progress) Written() bool { return false }
+func (p progress) Status() int   { return p.c.Response().StatusCode() }
+func (p progress) Size() int     { return len(p.c.Response().Body()) }
+
+// responseWriter is an http.ResponseWriter writing to the fasthttp
+// response of c, so errorid's net/http response path can be reused
+type responseWriter struct {
+	c           *fiber.Ctx
+	header      http.Header
+	wroteHeader bool
+}
+
+func newResponseWriter(c *fiber.Ctx) *responseWriter {
+	return &responseWriter{c: c, header: make(http.Header)}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader copies the headers set so far and the status to the
+// fasthttp response
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	
+	header := &w.c.Response().Header
+	for key, values := range w.header {
+		header.Del(key)
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+	w.c.Status(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.c.Response().AppendBody(p)
+	return len(p), nil
+}
+
+// statusError gives a 5xx *fiber.Error's status to errorid (StatusCoder)
+type statusError struct {
+	err    error
+	status int
+}
+
+func (e *statusError) Error() string   { return e.err.Error() }
+func (e *statusError) Unwrap() error   { return e.err }
+func (e *statusError) HTTPStatus() int { return e.status }
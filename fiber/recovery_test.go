@@ -0,0 +1,164 @@
+package erroridfiber
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// nopWriter discards log output
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+// newApp returns an app using Recovery and ErrorHandler with h, recording
+// the error ID the first middleware sees
+func newApp(h *errorid.Handler, seen *string) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(h)})
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		*seen = ErrorID(c)
+		return err
+	})
+	app.Use(Recovery(h))
+	return app
+}
+
+// decode reads the ErrorResponse of resp
+func decode(t *testing.T, resp *http.Response) errorid.ErrorResponse {
+	t.Helper()
+	var body errorid.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return body
+}
+
+func TestRecovery(t *testing.T) {
+	var wrapped *errorid.ErrorWithID
+	h := errorid.New(errorid.Config{
+		Logger:  errorid.NewDefaultLogger(nopWriter{}),
+		OnError: func(err *errorid.ErrorWithID) { wrapped = err },
+	})
+	
+	var seen string
+	app := newApp(h, &seen)
+	app.Get("/boom", func(c *fiber.Ctx) error {
+		h.WrapContext(c.UserContext(), errors.New("cache miss"), "load cache")
+		c.SendString("partial")
+		panic("nil cart")
+	})
+	
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/boom?x=1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", resp.StatusCode)
+	}
+	body := decode(t, resp)
+	if wrapped == nil || body.ErrorID != wrapped.ID || seen != wrapped.ID {
+		t.Fatalf("Expected the panic's ID in the response and locals, got %q / %q", body.ErrorID, seen)
+	}
+	if wrapped.PanicStack == "" || wrapped.Details["path"] != "/boom" || len(wrapped.Related) != 1 {
+		t.Errorf("Expected panic stack, request details and the related error, got %+v", wrapped)
+	}
+	if wrapped.Details["bytes_written"] != len("partial") {
+		t.Errorf("Expected the buffered bytes in details, got %v", wrapped.Details["bytes_written"])
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	var reported []*errorid.ErrorWithID
+	h := errorid.New(errorid.Config{
+		Logger:  errorid.NewDefaultLogger(nopWriter{}),
+		OnError: func(err *errorid.ErrorWithID) { reported = append(reported, err) },
+	})
+	
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(h)})
+	app.Use(Recovery(h))
+	app.Get("/db", func(c *fiber.Ctx) error {
+		return errors.New("connection refused")
+	})
+	app.Get("/forbidden", func(c *fiber.Ctx) error {
+		return h.NewHTTPError(http.StatusForbidden, "FORBIDDEN", "not yours", nil)
+	})
+	app.Get("/unavailable", func(c *fiber.Ctx) error {
+		return fiber.ErrServiceUnavailable
+	})
+	
+	tests := []struct {
+		path    string
+		status  int
+		message string
+	}{
+		{"/db", http.StatusInternalServerError, ""},
+		{"/forbidden", http.StatusForbidden, "not yours"},
+		{"/unavailable", http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		reported = nil
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, resp.StatusCode)
+		}
+		body := decode(t, resp)
+		if len(reported) != 1 || body.ErrorID != reported[0].ID {
+			t.Errorf("%s: expected one reported error with the response's ID, got %d / %q", tt.path, len(reported), body.ErrorID)
+		}
+		if tt.message != "" && body.Message != tt.message {
+			t.Errorf("%s: expected message %q, got %q", tt.path, tt.message, body.Message)
+		}
+		if tt.message == "" && body.Message == "connection refused" {
+			t.Errorf("%s: expected the internal message hidden", tt.path)
+		}
+	}
+	
+	// Fiber's own client errors are answered as usual, without a report
+	reported = nil
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusNotFound || string(text) != "Cannot GET /missing" || len(reported) != 0 {
+		t.Errorf("expected unreported 404, got %d %q (%d reported)", resp.StatusCode, text, len(reported))
+	}
+}
+
+// Test the Collector reaches handlers and echoed headers the response
+func TestRecoveryCorrelation(t *testing.T) {
+	h := errorid.New(errorid.Config{
+		Logger:      errorid.NewDefaultLogger(nopWriter{}),
+		EchoHeaders: []string{"X-Request-ID"},
+	})
+	
+	var seen string
+	app := newApp(h, &seen)
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		if errorid.CollectorFromContext(c.UserContext()) == nil {
+			t.Error("Expected a Collector in the user context")
+		}
+		return errors.New("boom")
+	})
+	
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decode(t, resp).Correlation["x-request-id"]; got != "req-42" {
+		t.Errorf("Expected X-Request-ID echoed, got %q", got)
+	}
+}
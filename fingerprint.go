@@ -0,0 +1,73 @@
+package errorid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fingerprint groups occurrences of the same error: it hashes the context,
+// the error code (or the type of the innermost error) and the function of
+// the panic site or wrap site (from Origin, else the stack trace). Messages and line
+// numbers are left out, so variable data and unrelated edits don't split
+// a group. Errors loaded from a Store keep the fingerprint they were saved with
+func (e *ErrorWithID) Fingerprint() string {
+	if e.fingerprint != "" {
+		return e.fingerprint
+	}
+	if e.label != nil && e.label.name == e.Context {
+		return e.label.fingerprint(fingerprintParts(e.Original, e.Origin, loggedStack(e)))
+	}
+	return fingerprint(e.Context, e.Original, e.Origin, loggedStack(e))
+}
+
+// fingerprint computes Fingerprint from the parts a Logger also receives
+func fingerprint(context string, original error, origin, stackTrace string) string {
+	kind, site := fingerprintParts(original, origin, stackTrace)
+	return fingerprintHash(context, kind, site)
+}
+
+// fingerprintParts returns the error kind and site parts of a fingerprint
+// The site is the panic site for recovered panics, else the wrap site
+func fingerprintParts(original error, origin, stackTrace string) (kind, site string) {
+	kind = ErrorCode(original)
+	if kind == "" {
+		kind = errorType(original)
+	}
+	
+	if panicStack, ok := strings.CutPrefix(stackTrace, panicStackHeader); ok {
+		site, _, _ = strings.Cut(panicStack, "\n")
+	} else if site = originFunction(origin); site == "" && stackTrace != "" {
+		// Top frame of the stack: "function\n\tfile:line\n..."
+		site, _, _ = strings.Cut(stackTrace, "\n")
+	}
+	
+	return kind, site
+}
+
+// fingerprintHash hashes the parts of a fingerprint
+func fingerprintHash(context, kind, site string) string {
+	sum := sha256.Sum256([]byte(context + "\x00" + kind + "\x00" + site))
+	return hex.EncodeToString(sum[:8])
+}
+
+// errorType returns the type name of the innermost error in err's chain
+func errorType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+// originFunction returns the function part of an Origin ("file:line function")
+func originFunction(origin string) string {
+	if i := strings.LastIndexByte(origin, ' '); i >= 0 {
+		return origin[i+1:]
+	}
+	return origin
+}
@@ -0,0 +1,98 @@
+package errorid
+
+import (
+	"context"
+	"fmt"
+	mrand "math/rand/v2"
+)
+
+// Flags are per-error overrides returned by a FlagProvider, to turn on
+// extra capture for targeted contexts in production without a redeploy
+// Zero fields keep the handler's behavior
+type Flags struct {
+	// StackTrace captures the stack trace even if IncludeStackTrace is off
+	StackTrace bool
+	
+	// SampleRate reports (logs and passes to OnError) only this fraction
+	// of errors, if between 0 and 1. Critical errors are always reported
+	SampleRate float64
+	
+	// Notifiers are also sent the error, in the background, if reported
+	Notifiers []Notifier
+}
+
+// FlagProvider looks up the Flags of each wrapped error, typically from
+// a feature flag service (LaunchDarkly, Unleash, ...) keyed on the error's
+// Context, Category or ErrorCode. It runs on every wrap, so it should
+// answer from the SDK's local cache
+type FlagProvider interface {
+	ErrorFlags(ctx context.Context, err *ErrorWithID) Flags
+}
+
+// FlagProviderFunc adapts a function to FlagProvider
+type FlagProviderFunc func(ctx context.Context, err *ErrorWithID) Flags
+
+// ErrorFlags calls f(ctx, err)
+func (f FlagProviderFunc) ErrorFlags(ctx context.Context, err *ErrorWithID) Flags {
+	return f(ctx, err)
+}
+
+// flagsFor asks the FlagProvider for the flags of err
+// A panicking provider is logged and treated as returning no flags
+func (h *Handler) flagsFor(ctx context.Context, err *ErrorWithID) (flags *Flags) {
+	if h.config.Flags == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	
+	defer func() {
+		if r := recover(); r != nil {
+			flags = nil
+			if h.config.Logger != nil {
+				h.config.Logger.Info("flag provider panicked: " + fmt.Sprint(r))
+			}
+		}
+	}()
+	
+	f := h.config.Flags.ErrorFlags(ctx, err)
+	if !f.StackTrace && f.SampleRate == 0 && len(f.Notifiers) == 0 {
+		return nil
+	}
+	return &f
+}
+
+// sampled reports whether err is reported under f (nil f reports all)
+func (f *Flags) sampled(err *ErrorWithID) bool {
+	if f == nil || f.SampleRate <= 0 || f.SampleRate >= 1 || isCritical(err) {
+		return true
+	}
+	return mrand.Float64() < f.SampleRate
+}
+
+// notifyFlagged sends err to the notifiers of its flags in the background
+// Flush waits for them; failures are logged
+func (h *Handler) notifyFlagged(err *ErrorWithID) {
+	if err.flags == nil || len(err.flags.Notifiers) == 0 || h.closed.Load() {
+		return
+	}
+	
+	for _, n := range err.flags.Notifiers {
+		h.inflight.Add(1)
+		go func(n Notifier) {
+			defer h.inflight.Done()
+			defer func() {
+				if r := recover(); r != nil && h.config.Logger != nil {
+					h.config.Logger.Info(fmt.Sprintf("flagged notifier panicked: %v (error %s)", r, err.ID))
+				}
+			}()
+	
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if notifyErr := n.Notify(ctx, err); notifyErr != nil && h.config.Logger != nil {
+				h.config.Logger.Info(fmt.Sprintf("flagged notifier failed: %v (error %s)", notifyErr, err.ID))
+			}
+		}(n)
+	}
+}
@@ -0,0 +1,157 @@
+package errorid
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// floodSweepSize is the number of tracked clients above which clients
+// with an expired window are pruned
+const floodSweepSize = 4096
+
+// floodMaxClients caps the tracked clients: past it, errors of new clients
+// are wrapped normally until windows expire
+const floodMaxClients = 65536
+
+// floodClientKey is the context key for the client address set by
+// RecoveryMiddleware when flood protection is on
+type floodClientKey struct{}
+
+// floodGuard counts errors per client in fixed windows and remembers each
+// client's last error, whose ID is reused past the threshold
+type floodGuard struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	clients   map[string]*floodClient
+	swept     time.Time // last sweep, at most one per window
+}
+
+// floodClient is the current window of one client
+type floodClient struct {
+	start time.Time
+	count int
+	last  *ErrorWithID
+}
+
+func newFloodGuard(threshold int, window time.Duration) *floodGuard {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &floodGuard{
+		threshold: threshold,
+		window:    window,
+		clients:   make(map[string]*floodClient),
+	}
+}
+
+// check counts an error of client at now and returns the client's last
+// error if it is over the threshold, nil otherwise
+func (g *floodGuard) check(client string, now time.Time) *ErrorWithID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	
+	c := g.clients[client]
+	if c == nil {
+		if len(g.clients) >= floodSweepSize && now.Sub(g.swept) >= g.window {
+			g.sweep(now)
+		}
+		if len(g.clients) >= floodMaxClients {
+			return nil
+		}
+		c = &floodClient{}
+		g.clients[client] = c
+	}
+	
+	if now.Sub(c.start) >= g.window {
+		c.start, c.count = now, 0
+	}
+	c.count++
+	
+	if c.count > g.threshold {
+		return c.last
+	}
+	return nil
+}
+
+// remember records err as client's last error
+func (g *floodGuard) remember(client string, err *ErrorWithID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	
+	if c := g.clients[client]; c != nil {
+		c.last = err
+	}
+}
+
+// sweep drops clients whose window has expired
+func (g *floodGuard) sweep(now time.Time) {
+	g.swept = now
+	for client, c := range g.clients {
+		if now.Sub(c.start) >= g.window {
+			delete(g.clients, client)
+		}
+	}
+}
+
+// withFloodClient returns ctx marked with the address of r's client
+// This is the peer address: behind a proxy or load balancer, clients
+// share the proxy's
+func withFloodClient(ctx context.Context, r *http.Request) context.Context {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return context.WithValue(ctx, floodClientKey{}, host)
+}
+
+// floodClientFrom returns the client address of ctx, or ""
+func floodClientFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	client, _ := ctx.Value(floodClientKey{}).(string)
+	return client
+}
+
+// flooded returns the client's last error when ctx's client is flooding,
+// or nil if err should be wrapped normally. Critical errors never are
+func (h *Handler) flooded(ctx context.Context, err error) (client string, cached *ErrorWithID) {
+	if h.flood == nil {
+		return "", nil
+	}
+	client = floodClientFrom(ctx)
+	if client == "" || severityOf(err) >= SeverityCritical {
+		return "", nil
+	}
+	
+	cached = h.flood.check(client, time.Now())
+	if cached != nil {
+		h.stats.floodSuppressed.Add(1)
+	}
+	return client, cached
+}
+
+// floodedError is err wrapped under the ID of the client's last error: the
+// caller keeps its own error chain (status, errors.Is/As), but no ID is
+// generated and nothing is logged, stored or reported
+func (h *Handler) floodedError(ctx context.Context, last *ErrorWithID, err error, context string, details map[string]interface{}) *ErrorWithID {
+	err = h.config.Translator.Translate(err)
+	return &ErrorWithID{
+		ID:          last.ID,
+		Original:    err,
+		Context:     context,
+		Details:     details,
+		Timestamp:   time.Now().Unix(),
+		IncidentID:  last.IncidentID,
+		Component:   h.config.Component,
+		Severity:    severityOf(err),
+		Category:    categoryOf(err),
+		Correlation: correlationFrom(ctx),
+		format:      h.errorFormat,
+		policy:      PolicyFromContext(ctx),
+	}
+}
@@ -0,0 +1,126 @@
+package errorid
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ForwardPath is the route of the forwarding protocol: a POST of one
+// error as JSON (the form used by spools and support bundles), answered
+// with 202 Accepted
+const ForwardPath = "/errorid/v1/errors"
+
+// ForwardSourceHeader names the process that forwarded an error
+// (Forwarder.Source); the agent records it as the "forwarded_from" detail
+const ForwardSourceHeader = "Errorid-Source"
+
+// maxForwardBody bounds a forwarded error
+const maxForwardBody = 1 << 20
+
+// Forwarder sends wrapped errors to an in-host agent (ForwardReceiver), so
+// sidecars and CLI tools report through the node's central configuration
+// (logger, store, notifiers) instead of each carrying their own:
+//
+//	fwd := errorid.NewForwarder("unix:/run/errorid/agent.sock")
+//	fwd.Source = "backup-cli"
+//	errorid.Configure(errorid.Config{OnError: fwd.OnError})
+//
+// Errors keep their IDs. Use it as a Route notifier to retry or act on
+// failures (Dispatcher.OnFailure)
+type Forwarder struct {
+	Source string // Sent in ForwardSourceHeader (optional)
+	
+	client *http.Client
+	url    string
+}
+
+// NewForwarder forwards to addr: "unix:" and a socket path, or a
+// localhost "host:port"
+func NewForwarder(addr string) *Forwarder {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		dialer := &net.Dialer{}
+		return &Forwarder{
+			client: &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", path)
+				},
+			}},
+			url: "http://errorid-agent" + ForwardPath,
+		}
+	}
+	return &Forwarder{client: http.DefaultClient, url: "http://" + addr + ForwardPath}
+}
+
+// Notify forwards err to the agent
+func (f *Forwarder) Notify(ctx context.Context, err *ErrorWithID) error {
+	var header http.Header
+	if f.Source != "" {
+		header = http.Header{ForwardSourceHeader: []string{f.Source}}
+	}
+	return postJSON(ctx, f.client, f.url, header, newBundleError(err))
+}
+
+// OnError is a Config.OnError callback forwarding err
+// Failures are dropped; wrap f in a Route to handle them
+func (f *Forwarder) OnError(err *ErrorWithID) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	f.Notify(ctx, err)
+}
+
+// ListenForward listens on addr for forwarders: "unix:" and a socket path
+// (a stale socket file is replaced), or a "host:port" that should be
+// loopback only
+//
+//	ln, err := errorid.ListenForward("unix:/run/errorid/agent.sock")
+//	go http.Serve(ln, agent.ForwardReceiver())
+func ListenForward(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+// ForwardReceiver returns the agent side of the forwarding protocol:
+// forwarded errors go through this handler's reporting (ID log,
+// interceptors, logger, store, OnError) with their original IDs, counted
+// in Stats.Forwarded. Serve it on a ListenForward listener; anything that
+// can reach it can report errors
+func (h *Handler) ForwardReceiver() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	
+		var record bundleError
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxForwardBody)).Decode(&record); err != nil {
+			http.Error(w, "invalid error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if record.ErrorID == "" {
+			http.Error(w, "invalid error: no error_id", http.StatusBadRequest)
+			return
+		}
+	
+		err := record.errorWithID()
+		if source := r.Header.Get(ForwardSourceHeader); source != "" {
+			if err.Details == nil {
+				err.Details = make(map[string]interface{}, 1)
+			}
+			err.Details["forwarded_from"] = source
+		}
+		h.stats.forwarded.Add(1)
+		h.reportPolicy(err)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -4,19 +4,57 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	mrand "math/rand/v2"
 	"time"
 )
 
+// IDFallback produces the 6-character random part of an error ID when
+// crypto/rand fails. Set it with Config.IDFallback
+type IDFallback func(now time.Time) string
+
+// MathRandFallback fills the random part from math/rand (auto-seeded)
+// This is the default fallback
+func MathRandFallback(now time.Time) string {
+	return fmt.Sprintf("%06x", mrand.Uint32()&0xffffff)
+}
+
+// TimestampFallback uses the sub-millisecond part of the clock
+// Kept for compatibility: IDs generated in the same microsecond collide
+func TimestampFallback(now time.Time) string {
+	return fmt.Sprintf("%06d", now.UnixNano()%1000000)
+}
+
+// randRead is crypto/rand.Read, replaceable in tests
+var randRead = rand.Read
+
 // GenerateErrorID creates a unique error ID with format: ERR-YYYYMMDD-XXXXXX
 // XXXXXX is a random hex string for collision resistance
 func GenerateErrorID() string {
-	date := time.Now().Format("20060102")
+	return generateErrorID(time.Now(), nil, nil)
+}
+
+// GenerateErrorIDIn is GenerateErrorID with the date taken in loc
+// Used when Config.TimeLocation is set, so IDs match business-day dashboards
+func GenerateErrorIDIn(loc *time.Location) string {
+	return generateErrorID(time.Now().In(loc), nil, nil)
+}
+
+// generateErrorID creates an error ID dated now
+// fallback (MathRandFallback if nil) replaces crypto/rand when it fails,
+// after onFallback (if set) is told why
+func generateErrorID(now time.Time, fallback IDFallback, onFallback func(error)) string {
+	date := now.Format("20060102")
 	randomBytes := make([]byte, 3) // 3 bytes = 6 hex chars
 	
-	_, err := rand.Read(randomBytes)
+	_, err := randRead(randomBytes)
 	if err != nil {
-		// Fallback to timestamp-based if crypto/rand fails
-		return fmt.Sprintf("ERR-%s-%06d", date, time.Now().UnixNano()%1000000)
+		if onFallback != nil {
+			onFallback(err)
+		}
+		if fallback == nil {
+			fallback = MathRandFallback
+		}
+		return fmt.Sprintf("ERR-%s-%s", date, fallback(now))
 	}
 	
 	randomHex := hex.EncodeToString(randomBytes)
@@ -0,0 +1,56 @@
+// Package erroridgin adapts errorid to Gin: Recovery recovers panics in
+// handlers, wraps them with an error ID and aborts with the JSON error
+// response
+package erroridgin
+
+import (
+	"time"
+	
+	"github.com/gin-gonic/gin"
+	
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// ContextKey is the gin.Context key holding the error ID of a recovered panic
+const ContextKey = "error_id"
+
+// Recovery returns Gin middleware doing what errorid's RecoveryMiddleware
+// does for net/http: requests get a Collector (and correlation headers,
+// flood tracking, capture as configured), and a panic is wrapped with an
+// error ID, stored under ContextKey, and answered with the JSON error
+// response unless one was already started. Handlers after it don't run
+// Use it instead of gin.Recovery:
+//
+//	r := gin.New()
+//	r.Use(gin.Logger(), erroridgin.Recovery(handler))
+//
+// If h is nil, the default errorid handler is used
+func Recovery(h *errorid.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handler := h
+		if handler == nil {
+			handler = errorid.Default()
+		}
+		
+		start := time.Now()
+		c.Request = handler.PrepareRequest(c.Request)
+		
+		defer func() {
+			if rec := recover(); rec != nil {
+				wrapped := handler.RecoverRequest(c.Request, rec, start, c.Writer)
+				c.Set(ContextKey, wrapped.ID)
+				c.Abort()
+				if !c.Writer.Written() {
+					handler.WriteError(c.Writer, wrapped)
+				}
+			}
+		}()
+		
+		c.Next()
+	}
+}
+
+// ErrorID returns the error ID Recovery stored in c, or ""
+func ErrorID(c *gin.Context) string {
+	return c.GetString(ContextKey)
+}
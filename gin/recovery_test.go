@@ -0,0 +1,92 @@
+package erroridgin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"github.com/gin-gonic/gin"
+	
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// nopWriter discards log output
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var wrapped *errorid.ErrorWithID
+	h := errorid.New(errorid.Config{
+		Logger:  errorid.NewDefaultLogger(nopWriter{}),
+		OnError: func(err *errorid.ErrorWithID) { wrapped = err },
+	})
+	
+	var seen string
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Next()
+		seen = ErrorID(c)
+	})
+	r.Use(Recovery(h))
+	r.GET("/boom", func(c *gin.Context) {
+		h.WrapContext(c.Request.Context(), errors.New("cache miss"), "load cache")
+		panic("nil cart")
+	}, func(c *gin.Context) {
+		t.Error("Expected handlers after the panic to be skipped")
+	})
+	
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	var resp errorid.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if wrapped == nil || resp.ErrorID != wrapped.ID || seen != wrapped.ID {
+		t.Fatalf("Expected the panic's ID in the response and context, got %q / %q", resp.ErrorID, seen)
+	}
+	if wrapped.PanicStack == "" || wrapped.Details["path"] != "/boom" || len(wrapped.Related) != 1 {
+		t.Errorf("Expected panic stack, request details and the related error, got %+v", wrapped)
+	}
+}
+
+func TestRecoveryAfterWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var wrapped *errorid.ErrorWithID
+	h := errorid.New(errorid.Config{
+		Logger:  errorid.NewDefaultLogger(nopWriter{}),
+		OnError: func(err *errorid.ErrorWithID) { wrapped = err },
+	})
+	
+	r := gin.New()
+	r.Use(Recovery(h))
+	r.GET("/stream", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("stream broke")
+	})
+	r.GET("/http", func(c *gin.Context) {
+		panic(h.NewHTTPError(http.StatusForbidden, "FORBIDDEN", "not yours", nil))
+	})
+	
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" {
+		t.Errorf("Expected the started response kept, got %d %q", rec.Code, rec.Body.String())
+	}
+	if wrapped == nil || wrapped.Details["status"] != http.StatusOK || wrapped.Details["bytes_written"] != 7 {
+		t.Errorf("Expected the sent status and size recorded, got %+v", wrapped)
+	}
+	
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/http", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected the HTTPError status 403, got %d", rec.Code)
+	}
+}
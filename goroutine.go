@@ -0,0 +1,39 @@
+package errorid
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/trace"
+	"strconv"
+)
+
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// "goroutine 42 [running]:" header of its stack (0 if that fails)
+// For correlating errors with execution traces and goroutine dumps only
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i > 0 {
+		header = header[:i]
+	}
+	id, _ := strconv.ParseUint(string(header), 10, 64)
+	return id
+}
+
+// traceWrap annotates a running runtime/trace with the wrapped error: it
+// logs the ID under the "errorid" category and opens a region named after
+// the context, to be ended when reporting is done. Events attach to the
+// task of ctx, if the caller started one with trace.NewTask
+func traceWrap(ctx context.Context, err *ErrorWithID) (end func()) {
+	if !trace.IsEnabled() {
+		return func() {}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	
+	trace.Log(ctx, "errorid", err.ID+" "+err.Context)
+	return trace.StartRegion(ctx, "errorid: "+err.Context).End
+}
@@ -0,0 +1,135 @@
+package errorid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Group is a drop-in for golang.org/x/sync/errgroup.Group whose goroutines
+// are panic-safe and whose errors are wrapped with IDs under the group's
+// context label. Each failing goroutine is reported; Wait returns the first
+// Like errgroup, the zero value works: it uses the default handler, no
+// context label and cancels nothing
+type Group struct {
+	handler *Handler
+	label   string
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	errOnce sync.Once
+	err     *ErrorWithID
+}
+
+// NewGroup creates a Group using the default handler, like errgroup.WithContext
+// The returned context is canceled when a goroutine fails or Wait returns
+func NewGroup(ctx context.Context, label string) (*Group, context.Context) {
+	return defaultFor(2).NewGroup(ctx, label)
+}
+
+// NewGroup creates a Group using this handler instance
+// label is the context of every error wrapped by the group
+func (h *Handler) NewGroup(ctx context.Context, label string) (*Group, context.Context) {
+	groupCtx, cancel := context.WithCancelCause(ctx)
+	g := &Group{
+		handler: h,
+		label:   label,
+		ctx:     groupCtx,
+		cancel:  cancel,
+	}
+	return g, groupCtx
+}
+
+// SetLimit limits the number of active goroutines to n (negative = no limit)
+// Must not be called while goroutines are active
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic(fmt.Errorf("errorid: modify limit while %v goroutines in the group are still active", len(g.sem)))
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs f in a new goroutine, blocking while the limit is reached
+// A panic in f is recovered and reported like a returned error
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(f)
+}
+
+// TryGo runs f in a new goroutine only if the limit allows it
+// Reports whether the goroutine was started
+func (g *Group) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(f)
+	return true
+}
+
+// Wait blocks until all goroutines finish and returns the first error
+// (an *ErrorWithID), or nil
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(nil)
+	}
+	if g.err == nil {
+		return nil
+	}
+	return g.err
+}
+
+// start launches f; the semaphore slot (if any) is already taken
+func (g *Group) start(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+			g.wg.Done()
+		}()
+		
+		if err := g.run(f); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+		}
+	}()
+}
+
+// run calls f, converting panics and errors to wrapped errors
+func (g *Group) run(f func() error) *ErrorWithID {
+	handler, ctx := g.handler, g.ctx
+	if handler == nil {
+		// Zero Group
+		handler, ctx = defaultFor(1), context.Background()
+	}
+	
+	return handler.runProtected(ctx, g.label, func() error {
+		err := f()
+		
+		// Cancellation caused by an earlier failure in the group is not a new error
+		var cause *ErrorWithID
+		if errors.Is(err, context.Canceled) && errors.As(context.Cause(ctx), &cause) {
+			return nil
+		}
+		return err
+	})
+}
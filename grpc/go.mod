@@ -0,0 +1,18 @@
+module github.com/isaui/go-support-id-error/grpc
+
+go 1.24.4
+
+require (
+	github.com/isaui/go-support-id-error v0.0.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250929231259-57b25ae835d4
+	google.golang.org/grpc v1.75.1
+)
+
+require (
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
+	google.golang.org/protobuf v1.36.9 // indirect
+)
+
+replace github.com/isaui/go-support-id-error => ../
@@ -0,0 +1,170 @@
+// Package erroridgrpc adapts errorid to gRPC servers: interceptors recover
+// panics, wrap returned errors with an error ID and attach the ID to the
+// gRPC status, where clients, and grpc-gateway (erroridgateway), find it
+package erroridgrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// MetadataKey is the ErrorInfo metadata key holding the error ID, as read
+// by erroridgateway
+const MetadataKey = "error_id"
+
+// UnaryServerInterceptor returns an interceptor doing for unary RPCs what
+// RecoveryMiddleware does for HTTP: calls get a Collector, and panics and
+// returned errors are wrapped with an ID (context: the full method name),
+// logged and reported as configured, then returned as a gRPC status
+// carrying the ID in an errdetails.ErrorInfo:
+//
+//	srv := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(erroridgrpc.UnaryServerInterceptor(handler)),
+//		grpc.ChainStreamInterceptor(erroridgrpc.StreamServerInterceptor(handler)),
+//	)
+//
+// Errors that already have an ID keep it. If h is nil, the default errorid
+// handler is used
+func UnaryServerInterceptor(h *errorid.Handler) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		h := resolve(h)
+		ctx = prepare(ctx, info.FullMethod)
+	
+		var resp interface{}
+		wrapped := h.TryContext(ctx, info.FullMethod, func() error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		if wrapped != nil {
+			return nil, Status(h, wrapped).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming RPCs
+// The stream's Context carries the Collector
+func StreamServerInterceptor(h *errorid.Handler) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		h := resolve(h)
+		stream := &serverStream{ServerStream: ss, ctx: prepare(ss.Context(), info.FullMethod)}
+	
+		wrapped := h.TryContext(stream.ctx, info.FullMethod, func() error {
+			return handler(srv, stream)
+		})
+		if wrapped != nil {
+			return Status(h, wrapped).Err()
+		}
+		return nil
+	}
+}
+
+// Status converts a wrapped error to a gRPC status with its ID in an
+// ErrorInfo detail. A status in err's chain keeps its code, message and
+// details; otherwise the code follows the error's HTTP status (see
+// errorid.StatusCoder) and the message is the one h would send to HTTP
+// clients
+func Status(h *errorid.Handler, err *errorid.ErrorWithID) *status.Status {
+	var st *status.Status
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	switch {
+	case errors.As(err, &grpcErr):
+		st = grpcErr.GRPCStatus()
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		st = status.FromContextError(err)
+	default:
+		st = status.New(codeFor(errorid.HTTPStatus(err)), resolve(h).ErrorResponse(err).Message)
+	}
+	
+	reason := errorid.ErrorCode(err)
+	if reason == "" {
+		reason = st.Code().String()
+	}
+	metadata := map[string]string{MetadataKey: err.ID}
+	if err.IncidentID != "" {
+		metadata["incident_id"] = err.IncidentID
+	}
+	
+	withID, detailsErr := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Metadata: metadata})
+	if detailsErr != nil {
+		return st
+	}
+	return withID
+}
+
+// ErrorID returns the error ID carried by a gRPC error, for clients
+// Returns "" if err has none
+func ErrorID(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if id := info.GetMetadata()[MetadataKey]; id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// resolve returns h, or the default handler if h is nil
+func resolve(h *errorid.Handler) *errorid.Handler {
+	if h == nil {
+		return errorid.Default()
+	}
+	return h
+}
+
+// prepare gives a call's context a Collector and the method as a detail
+func prepare(ctx context.Context, method string) context.Context {
+	ctx, _ = errorid.WithCollector(ctx)
+	return errorid.WithDetails(ctx, map[string]interface{}{"grpc_method": method})
+}
+
+// codeFor maps an HTTP status to the closest gRPC code (Internal for 0)
+func codeFor(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// serverStream overrides the context of a grpc.ServerStream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
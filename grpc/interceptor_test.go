@@ -0,0 +1,130 @@
+package erroridgrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// nopWriter discards log output
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+// newHandler returns a handler recording reported errors in *reported
+func newHandler(reported *[]*errorid.ErrorWithID) *errorid.Handler {
+	return errorid.New(errorid.Config{
+		Logger:  errorid.NewDefaultLogger(nopWriter{}),
+		OnError: func(err *errorid.ErrorWithID) { *reported = append(*reported, err) },
+	})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var reported []*errorid.ErrorWithID
+	h := newHandler(&reported)
+	interceptor := UnaryServerInterceptor(h)
+	info := &grpc.UnaryServerInfo{FullMethod: "/shop.Orders/Get"}
+	errQuota := errorid.Define("QUOTA", http.StatusTooManyRequests, "quota exceeded")
+	
+	tests := []struct {
+		name    string
+		handler grpc.UnaryHandler
+		code    codes.Code
+		message string
+		panic   bool
+	}{
+		{"error", func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, errors.New("db: connection refused")
+		}, codes.Internal, "", false},
+		{"status", func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "order not found")
+		}, codes.NotFound, "order not found", false},
+		{"definition", func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, errQuota.NewWith(h, nil)
+		}, codes.ResourceExhausted, "quota exceeded", false},
+		{"panic", func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("nil order")
+		}, codes.Internal, "", true},
+	}
+	for _, tt := range tests {
+		reported = nil
+		resp, err := interceptor(context.Background(), nil, info, tt.handler)
+		
+		st := status.Convert(err)
+		if resp != nil || st.Code() != tt.code {
+			t.Errorf("%s: expected code %v, got %v (%v)", tt.name, tt.code, st.Code(), err)
+		}
+		if tt.message != "" && st.Message() != tt.message {
+			t.Errorf("%s: expected message %q, got %q", tt.name, tt.message, st.Message())
+		}
+		if st.Message() == "db: connection refused" {
+			t.Errorf("%s: expected the internal message hidden", tt.name)
+		}
+		if len(reported) != 1 || ErrorID(err) != reported[0].ID {
+			t.Fatalf("%s: expected one reported error with the status's ID, got %d / %q", tt.name, len(reported), ErrorID(err))
+		}
+		// Errors wrapped by the handler itself keep their own details
+		if tt.name == "definition" {
+			continue
+		}
+		if reported[0].Details["grpc_method"] != info.FullMethod || (reported[0].Details["panic"] == true) != tt.panic {
+			t.Errorf("%s: expected the method and panic details, got %v", tt.name, reported[0].Details)
+		}
+	}
+	
+	resp, err := interceptor(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		if errorid.CollectorFromContext(ctx) == nil {
+			t.Error("Expected a Collector in the call context")
+		}
+		return "ok", nil
+	})
+	if resp != "ok" || err != nil || len(reported) != 1 {
+		t.Errorf("Expected successful calls untouched, got %v, %v", resp, err)
+	}
+}
+
+// fakeStream is a grpc.ServerStream with only a context
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	var reported []*errorid.ErrorWithID
+	h := newHandler(&reported)
+	interceptor := StreamServerInterceptor(h)
+	info := &grpc.StreamServerInfo{FullMethod: "/shop.Orders/Watch", IsServerStream: true}
+	
+	err := interceptor(nil, &fakeStream{ctx: context.Background()}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		h.WrapContext(stream.Context(), errors.New("cache miss"), "load cache")
+		panic("stream broke")
+	})
+	
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Expected Internal, got %v", err)
+	}
+	if len(reported) != 2 || ErrorID(err) != reported[1].ID {
+		t.Fatalf("Expected the panic's ID in the status, got %q", ErrorID(err))
+	}
+	if len(reported[1].Related) != 1 || reported[1].Related[0] != reported[0].ID {
+		t.Errorf("Expected the earlier error of the call as related, got %v", reported[1].Related)
+	}
+}
+
+func TestErrorID(t *testing.T) {
+	if id := ErrorID(errors.New("plain")); id != "" {
+		t.Errorf("Expected no ID, got %q", id)
+	}
+	if id := ErrorID(status.Error(codes.NotFound, "missing")); id != "" {
+		t.Errorf("Expected no ID, got %q", id)
+	}
+}
@@ -0,0 +1,89 @@
+// Package erroridgateway keeps error IDs intact across the grpc-gateway
+// boundary by re-emitting the ID from gRPC status details in the HTTP JSON
+// error response
+package erroridgateway
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// MetadataKey is the ErrorInfo metadata key holding the error ID
+const MetadataKey = "error_id"
+
+// ErrorIDFromStatus returns the error ID carried by an ErrorInfo detail of st
+// Returns "" if st has no such detail
+func ErrorIDFromStatus(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if id := info.GetMetadata()[MetadataKey]; id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// ErrorHandler returns a grpc-gateway error handler for runtime.WithErrorHandler
+// Errors whose gRPC status carries an ID keep it, and the status message,
+// which the errorid-aware server already chose for clients; other errors
+// (e.g. failures in the gateway itself) are wrapped with h to get a fresh
+// one. The response is written like errorid's WriteError: fields follow
+// h's ResponseDetail, and extensions, signing and cache headers apply
+// If h is nil, the default errorid handler is used
+func ErrorHandler(h *errorid.Handler) runtime.ErrorHandlerFunc {
+	return func(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+		handler := h
+		if handler == nil {
+			handler = errorid.Default()
+		}
+		
+		st := status.Convert(err)
+		cause := &statusError{err: err, st: st}
+		
+		// Upstream ID: the error was reported by the errorid-aware server
+		if id := ErrorIDFromStatus(st); id != "" {
+			cause.public = true
+			handler.WriteError(w, &errorid.ErrorWithID{
+				ID:        id,
+				Original:  cause,
+				Timestamp: time.Now().Unix(),
+			})
+			return
+		}
+		
+		// No upstream ID: the error never reached an errorid-aware server
+		wrapped := handler.WrapWithDetails(cause, "grpc-gateway request failed", map[string]interface{}{
+			"method":    r.Method,
+			"path":      r.URL.Path,
+			"grpc_code": st.Code().String(),
+		})
+		handler.WriteError(w, wrapped)
+	}
+}
+
+// statusError carries the HTTP status of a gRPC error and, for errors from
+// errorid-aware servers, its status message as the public message
+type statusError struct {
+	err    error
+	st     *status.Status
+	public bool
+}
+
+func (e *statusError) Error() string   { return e.err.Error() }
+func (e *statusError) Unwrap() error   { return e.err }
+func (e *statusError) HTTPStatus() int { return runtime.HTTPStatusFromCode(e.st.Code()) }
+
+func (e *statusError) PublicMessage() string {
+	if !e.public {
+		return ""
+	}
+	return e.st.Message()
+}
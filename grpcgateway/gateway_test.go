@@ -0,0 +1,112 @@
+package erroridgateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+func TestErrorHandlerKeepsUpstreamID(t *testing.T) {
+	st, err := status.New(codes.NotFound, "order not found").WithDetails(&errdetails.ErrorInfo{
+		Reason:   "NOT_FOUND",
+		Metadata: map[string]string{MetadataKey: "ERR-20250101-abc123"},
+	})
+	if err != nil {
+		t.Fatalf("failed to build status: %v", err)
+	}
+	
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil)
+	ErrorHandler(errorid.New(errorid.Config{}))(context.Background(), nil, nil, rec, req, st.Err())
+	
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rec.Code)
+	}
+	
+	var resp errorid.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	
+	if resp.ErrorID != "ERR-20250101-abc123" {
+		t.Errorf("expected upstream error ID, got %s", resp.ErrorID)
+	}
+	
+	if resp.Message != "order not found" {
+		t.Errorf("expected status message, got %s", resp.Message)
+	}
+}
+
+func TestErrorHandlerMintsIDWithoutUpstream(t *testing.T) {
+	var captured *errorid.ErrorWithID
+	h := errorid.New(errorid.Config{
+		OnError: func(err *errorid.ErrorWithID) {
+			captured = err
+		},
+	})
+	
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil)
+	ErrorHandler(h)(context.Background(), nil, nil, rec, req, errors.New("dial failed"))
+	
+	if captured == nil {
+		t.Fatal("expected gateway error to be wrapped")
+	}
+	
+	var resp errorid.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	
+	if resp.ErrorID != captured.ID {
+		t.Errorf("expected minted error ID %s, got %s", captured.ID, resp.ErrorID)
+	}
+}
+
+func TestErrorHandlerHidesGatewayErrors(t *testing.T) {
+	h := errorid.New(errorid.Config{Environment: "production"})
+	
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil)
+	ErrorHandler(h)(context.Background(), nil, nil, rec, req, errors.New("dial tcp 10.0.0.7:9090: connection refused"))
+	
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rec.Code)
+	}
+	
+	var resp errorid.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	
+	if strings.Contains(resp.Message, "10.0.0.7") {
+		t.Errorf("internal error leaked to client: %s", resp.Message)
+	}
+}
+
+func TestErrorHandlerConcurrentDefault(t *testing.T) {
+	handler := ErrorHandler(nil)
+	
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil)
+			handler(context.Background(), nil, nil, rec, req, status.Error(codes.Unavailable, "down"))
+		}()
+	}
+	wg.Wait()
+}
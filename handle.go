@@ -0,0 +1,51 @@
+package errorid
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HandlerFuncE is an HTTP handler that returns its failure instead of
+// writing the error response itself (see Handle)
+type HandlerFuncE func(w http.ResponseWriter, r *http.Request) error
+
+// Handle adapts fn to http.Handler using the default handler
+func Handle(fn HandlerFuncE) http.Handler {
+	return defaultFor(2).Handle(fn)
+}
+
+// Handle adapts fn to http.Handler: a returned error is wrapped with an ID
+// (errors that already have one keep it) with the request's method and
+// path as details, and answered with the JSON error response, its status
+// from the error chain (definitions, HTTPError, StatusCoder) or 500:
+//
+//	mux.Handle("GET /orders/{id}", handler.Handle(func(w http.ResponseWriter, r *http.Request) error {
+//		order, err := store.Order(r.Context(), r.PathValue("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return json.NewEncoder(w).Encode(order)
+//	}))
+//
+// If fn already started a response, the error is reported but nothing
+// more is written. Panics are left to RecoveryMiddleware
+func (h *Handler) Handle(fn HandlerFuncE) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseRecorder{ResponseWriter: w}
+		err := fn(rw, r)
+		if err == nil {
+			return
+		}
+		
+		var wrapped *ErrorWithID
+		if !errors.As(err, &wrapped) {
+			wrapped = h.wrap(r.Context(), err, "HTTP handler failed", map[string]interface{}{
+				"method": r.Method,
+				"path":   r.URL.Path,
+			})
+		}
+		if !rw.wroteHeader {
+			h.WriteErrorRequest(w, r, wrapped)
+		}
+	})
+}
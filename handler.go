@@ -1,31 +1,154 @@
 package errorid
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 )
 
 // Handler manages error wrapping and tracking
 type Handler struct {
-	config Config
+	config      Config
+	report      WrapFunc           // reporting pipeline with interceptors applied
+	callerSkip  int                // extra frames to skip for Origin and stack traces
+	errorFormat *template.Template // parsed Config.ErrorFormat
+	stats       *handlerStats
+	build       *BuildInfo      // stamped on every error (nil if unknown or disabled)
+	pending     chan struct{}   // async callback slots (MaxPendingCallbacks)
+	queue       *callbackQueue  // calls waiting for a slot (CallbackQueueSize)
+	inflight    *sync.WaitGroup // running async callbacks, for Flush
+	closed      *atomic.Bool    // set by Close
+	incidents   *incidentTracker
+	flood       *floodGuard // per-client error floods (FloodThreshold)
+	captures    *captureLog // requests kept for ReplayHandler (CaptureRequests)
+	once        *onceTracker
+	telemetry   *telemetry
 }
 
 // New creates a new Handler instance with custom configuration
 // This is the instance-based API for advanced use cases
 func New(cfg Config) *Handler {
+	// Use default logger if not provided
+	if cfg.Logger == nil {
+		cfg.Logger = DefaultConfig().Logger
+	}
+	
+	// Default logger timestamps follow TimeLocation
+	if l, ok := cfg.Logger.(*DefaultLogger); ok && cfg.TimeLocation != nil && l.loc == nil {
+		cfg.Logger = l.In(cfg.TimeLocation)
+	}
+	
+	// Default logger lines name the component
+	if l, ok := cfg.Logger.(*DefaultLogger); ok && cfg.Component != "" && l.component == "" {
+		cfg.Logger = l.Named(cfg.Component)
+	}
+	
+	h := &Handler{
+		config:   cfg,
+		stats:    &handlerStats{},
+		inflight: &sync.WaitGroup{},
+		closed:   &atomic.Bool{},
+		once:     newOnceTracker(cfg.WrapOnceWindow),
+	}
+	
+	if cfg.MaxPendingCallbacks > 0 {
+		h.pending = make(chan struct{}, cfg.MaxPendingCallbacks)
+		if cfg.CallbackQueueSize > 0 {
+			h.queue = newCallbackQueue(cfg.CallbackQueueSize)
+		}
+	}
+	
+	if cfg.IncidentThreshold > 0 {
+		h.incidents = newIncidentTracker(cfg.IncidentThreshold, cfg.IncidentWindow)
+	}
+	
+	if cfg.FloodThreshold > 0 {
+		h.flood = newFloodGuard(cfg.FloodThreshold, cfg.FloodWindow)
+	}
+	
+	if cfg.CaptureRequests && cfg.Environment == "development" {
+		h.captures = newCaptureLog()
+	}
+	
+	if !cfg.DisableBuildInfo {
+		h.build = currentBuild()
+	}
+	
+	// Attach allowlisted environment variables to every error
+	h.applyEnvDetails()
+	
+	// Stores key errors by ID: shared IDs would collide
+	if cfg.IDMode == IDPerRequest && cfg.Store != nil {
+		cfg.Logger.Info("IDPerRequest can't be used with a Store, using IDPerError")
+		h.config.IDMode = IDPerError
+	}
+	
 	// Use default ID generator if not provided
 	if cfg.IDGenerator == nil {
-		cfg.IDGenerator = GenerateErrorID
+		h.config.IDGenerator = h.generateID
 	}
 	
-	// Use default logger if not provided
-	if cfg.Logger == nil {
-		cfg.Logger = DefaultConfig().Logger
+	// Parse custom Error() layout, keeping the default if invalid
+	if cfg.ErrorFormat != "" {
+		format, err := parseErrorFormat(cfg.ErrorFormat)
+		if err != nil {
+			cfg.Logger.Info("invalid ErrorFormat, using default layout: " + err.Error())
+		} else {
+			h.errorFormat = format
+		}
+	}
+	
+	if cfg.TelemetryEndpoint != "" {
+		h.telemetry = startTelemetry(h, cfg.TelemetryInterval)
 	}
 	
-	return &Handler{
-		config: cfg,
+	// Build interceptor chain: first interceptor is the outermost
+	h.report = h.logAndNotify
+	for i := len(cfg.Interceptors) - 1; i >= 0; i-- {
+		h.report = cfg.Interceptors[i](h.report)
 	}
+	
+	return h
+}
+
+// generateID is the default IDGenerator
+// crypto/rand failures are counted, logged once and served by IDFallback
+func (h *Handler) generateID() string {
+	now := time.Now()
+	if h.config.TimeLocation != nil {
+		now = now.In(h.config.TimeLocation)
+	}
+	if h.config.IDPool != nil {
+		if suffix, ok := h.config.IDPool.suffix(); ok {
+			return "ERR-" + now.Format("20060102") + "-" + suffix
+		}
+	}
+	return generateErrorID(now, h.config.IDFallback, h.idFallbackUsed)
+}
+
+// idFallbackUsed records a crypto/rand failure
+func (h *Handler) idFallbackUsed(err error) {
+	if h.stats.idFallbacks.Add(1) == 1 && h.config.Logger != nil {
+		h.config.Logger.Info("crypto/rand failed, error IDs use IDFallback: " + err.Error())
+	}
+}
+
+// WithCallerSkip returns a handler that attributes errors n frames further
+// up the stack. Use it in your own thin wrappers around Wrap so Origin and
+// stack traces point at their callers instead of the wrapper:
+//
+//	var wrapper = errorid.Default().WithCallerSkip(1)
+//
+//	func dbError(err error) error { return wrapper.Wrap(err, "database") }
+//
+// The returned handler shares the configuration of h
+func (h *Handler) WithCallerSkip(n int) *Handler {
+	clone := *h
+	clone.callerSkip += n
+	return &clone
 }
 
 // Wrap wraps an error with a unique ID and logs it
@@ -35,77 +158,474 @@ func (h *Handler) Wrap(err error, context string) *ErrorWithID {
 
 // WrapWithDetails wraps error with additional metadata
 func (h *Handler) WrapWithDetails(err error, context string, details map[string]interface{}) *ErrorWithID {
+	return h.wrap(nil, err, context, details)
+}
+
+// Newf creates a new error from a format string and wraps it, for code
+// paths that originate errors rather than wrapping upstream ones
+// Like fmt.Errorf, %w in format keeps the wrapped error in the chain
+func (h *Handler) Newf(format string, args ...interface{}) *ErrorWithID {
+	return h.wrap(nil, fmt.Errorf(format, args...), "", nil)
+}
+
+// wrap builds the ErrorWithID and reports it
+// ctx may be nil when the caller has no request context
+func (h *Handler) wrap(ctx context.Context, err error, context string, details map[string]interface{}) *ErrorWithID {
+	return h.wrapLabel(ctx, err, context, nil, details)
+}
+
+// wrapLabel is wrap with the ContextLabel of context, if declared as one
+func (h *Handler) wrapLabel(ctx context.Context, err error, context string, label *ContextLabel, details map[string]interface{}) *ErrorWithID {
 	if err == nil {
 		return nil
 	}
+	if context == "" && h.config.InferContext {
+		context = inferContext(h.callerSkip)
+	}
+	
+	if earlier := h.collapsed(ctx, err, context); earlier != nil {
+		return earlier
+	}
+	
+	client, last := h.flooded(ctx, err)
+	if last != nil {
+		return h.floodedError(ctx, last, err, context, details)
+	}
+	
+	wrapped := h.newError(ctx, err, context, details)
+	wrapped.label = label
+	if h.config.AnnotateTrace {
+		defer traceWrap(ctx, wrapped)()
+	}
+	wrapped = h.reportPolicy(h.classify(wrapped))
+	if client != "" {
+		h.flood.remember(client, wrapped)
+	}
+	return wrapped
+}
+
+// collapsed returns the earlier error of the request that err re-wraps,
+// if DoubleReportGuard is on
+func (h *Handler) collapsed(ctx context.Context, err error, context string) *ErrorWithID {
+	if !h.config.DoubleReportGuard {
+		return nil
+	}
+	c := CollectorFromContext(ctx)
+	if c == nil {
+		return nil
+	}
+	
+	earlier := c.collapse(err, context, h.trailEntry(context))
+	if earlier != nil {
+		h.stats.collapsed.Add(1)
+	}
+	return earlier
+}
+
+// classify assigns the incident of a fully built error
+func (h *Handler) classify(wrapped *ErrorWithID) *ErrorWithID {
+	if h.incidents != nil {
+		wrapped.IncidentID = h.incidents.observe(wrapped.Fingerprint(), time.Now())
+	}
+	return wrapped
+}
+
+// wrapPanic is wrap for recovered panics, attaching the panic site stack
+// captured by capturePanicStack
+func (h *Handler) wrapPanic(ctx context.Context, err error, context string, details map[string]interface{}, panicStack string) *ErrorWithID {
+	if pe, ok := err.(*panicError); ok && h.config.SanitizePanic != nil && pe.sanitized == nil {
+		sanitized := h.config.SanitizePanic(pe.value)
+		pe.sanitized = &sanitized
+	}
+	if context == "" && h.config.InferContext {
+		context = inferContext(h.callerSkip)
+	}
+	
+	if earlier := h.collapsed(ctx, err, context); earlier != nil {
+		return earlier
+	}
 	
-	errorID := h.config.IDGenerator()
+	client, last := h.flooded(ctx, err)
+	if last != nil {
+		return h.floodedError(ctx, last, err, context, details)
+	}
+	
+	wrapped := h.newError(ctx, err, context, details)
+	wrapped.PanicStack = panicStack
+	if h.config.AnnotateTrace {
+		defer traceWrap(ctx, wrapped)()
+	}
+	wrapped = h.reportPolicy(h.classify(wrapped))
+	if client != "" {
+		h.flood.remember(client, wrapped)
+	}
+	return wrapped
+}
+
+// newError builds the ErrorWithID for a non-nil err
+func (h *Handler) newError(ctx context.Context, err error, context string, details map[string]interface{}) *ErrorWithID {
+	h.stats.wrapped.Add(1)
+	h.stats.rates.errored(context)
+	err = h.config.Translator.Translate(err)
+	
+	// Merge default and context details without mutating any map
+	if ctxDetails := DetailsFromContext(ctx); len(h.config.DefaultDetails) > 0 || len(ctxDetails) > 0 {
+		merged := make(map[string]interface{}, len(h.config.DefaultDetails)+len(ctxDetails)+len(details))
+		for k, v := range h.config.DefaultDetails {
+			merged[k] = v
+		}
+		for k, v := range ctxDetails {
+			merged[k] = v
+		}
+		for k, v := range details {
+			merged[k] = v
+		}
+		details = merged
+	}
+	
+	errorID := h.errorID(ctx)
 	
 	wrapped := &ErrorWithID{
-		ID:        errorID,
-		Original:  err,
-		Context:   context,
-		Details:   details,
-		Timestamp: time.Now().Unix(),
+		ID:          errorID,
+		Original:    err,
+		Context:     context,
+		Details:     details,
+		Timestamp:   time.Now().Unix(),
+		Build:       h.build,
+		Component:   h.config.Component,
+		Severity:    severityOf(err),
+		Category:    categoryOf(err),
+		Attachments: attachmentsOf(err),
+		Causes:      causesOf(err),
+		format:      h.errorFormat,
 	}
 	
 	// Capture stack trace if enabled
 	if h.config.IncludeStackTrace {
-		wrapped.StackTrace = captureStackTrace(2) // skip this function and Wrap
+		wrapped.StackTrace = captureStackTrace(h.callerSkip)
+	}
+	
+	// Capture only the wrap site if enabled
+	if h.config.IncludeOrigin {
+		wrapped.Origin = captureOrigin(h.callerSkip)
+	}
+	
+	wrapped.Correlation = correlationFrom(ctx)
+	
+	if h.config.AnnotateTrace {
+		wrapped.Goroutine = goroutineID()
+	}
+	
+	// Record this layer after those of earlier wraps in the chain
+	if entry := h.trailEntry(context); entry != nil {
+		entry.ErrorID = errorID
+		wrapped.Trail = trailOf(err, entry)
+	}
+	
+	// Keep the failed request for replay (development only)
+	if h.captures != nil {
+		if wrapped.Request = capturedFrom(ctx); wrapped.Request != nil && wrapped.ID != "" {
+			h.captures.add(wrapped.ID, wrapped.Request)
+		}
+	}
+	
+	// Route policy: default category for routing, sampling, verbosity
+	if wrapped.policy = PolicyFromContext(ctx); wrapped.policy != nil && wrapped.Category == "" {
+		wrapped.Category = wrapped.policy.Category
+	}
+	
+	// Link errors wrapped during the same request (only distinct IDs)
+	if c := CollectorFromContext(ctx); c != nil {
+		wrapped.Breadcrumbs = c.Breadcrumbs()
+		related := c.add(wrapped)
+		if h.config.IDMode == IDPerError {
+			wrapped.Related = related
+		}
 	}
 	
+	// Feature flags: extra capture, sampling and notifiers for this error
+	if wrapped.flags = h.flagsFor(ctx, wrapped); wrapped.flags != nil && wrapped.flags.StackTrace && wrapped.StackTrace == "" {
+		wrapped.StackTrace = captureStackTrace(h.callerSkip)
+	}
+	
+	return wrapped
+}
+
+// errorID returns the ID of a new error according to Config.IDMode
+func (h *Handler) errorID(ctx context.Context) string {
+	switch h.config.IDMode {
+	case IDNone:
+		return ""
+	case IDPerRequest:
+		if c := CollectorFromContext(ctx); c != nil {
+			return c.requestID(h.config.IDGenerator)
+		}
+	}
+	return h.config.IDGenerator()
+}
+
+// logAndNotify is the innermost WrapFunc: it logs the error and runs OnError
+func (h *Handler) logAndNotify(wrapped *ErrorWithID) *ErrorWithID {
+	// Evaluate lazy details now that the error is reported
+	wrapped.Details = h.resolveDetails(wrapped.Details)
+	
 	// Log the error
 	h.logError(wrapped)
+	h.saveError(wrapped)
 	
 	// Execute OnError callback
 	if h.config.OnError != nil {
+		// Callbacks may be kept from seeing stacks; the caller still gets them
+		notified := wrapped
+		if !h.config.StackTraceTargets.has(StackToCallbacks) && (wrapped.StackTrace != "" || wrapped.PanicStack != "") {
+			stackless := *wrapped
+			stackless.StackTrace, stackless.PanicStack = "", ""
+			notified = &stackless
+		}
+		
 		if h.config.AsyncCallback {
-			// Async: run in goroutine
-			go h.safeCallback(wrapped)
+			// Closed: nothing would wait for the goroutine
+			if h.closed.Load() {
+				h.stats.callbackDropped.Add(1)
+				h.callbackFailed(notified, fmt.Errorf("%w: handler closed", ErrCallbackDropped))
+				return wrapped
+			}
+			
+			// Async: run in goroutine, unless too many are in flight, in
+			// which case it waits in the queue or is dropped
+			// Critical errors are never dropped: they run without a slot
+			acquired := false
+			if h.pending != nil {
+				select {
+				case h.pending <- struct{}{}:
+					acquired = true
+				default:
+					if !isCritical(notified) {
+						if h.queue != nil {
+							h.enqueueCallback(notified)
+							return wrapped
+						}
+						h.stats.callbackDropped.Add(1)
+						h.callbackFailed(notified, ErrCallbackDropped)
+						return wrapped
+					}
+				}
+			}
+			h.inflight.Add(1)
+			go h.runAsync(notified, acquired)
 		} else {
 			// Sync: blocking call
-			h.safeCallback(wrapped)
+			h.runCallback(notified)
 		}
 	}
 	
 	return wrapped
 }
 
+// runCallback runs OnError, enforcing CallbackTimeout if set
+// A timed-out callback keeps running in the background, but the wrap
+// (sync mode) no longer waits for it
+func (h *Handler) runCallback(err *ErrorWithID) {
+	timeout := h.config.CallbackTimeout
+	if timeout <= 0 {
+		h.safeCallback(err)
+		return
+	}
+	
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.safeCallback(err)
+	}()
+	
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	
+	select {
+	case <-done:
+	case <-timer.C:
+		h.stats.callbackTimeouts.Add(1)
+		h.callbackFailed(err, fmt.Errorf("%w after %v", ErrCallbackTimeout, timeout))
+	}
+}
+
 // logError logs the error using configured logger
 func (h *Handler) logError(err *ErrorWithID) {
 	if h.config.Logger == nil {
 		return
 	}
 	
-	// Copy user details (don't mutate original)
-	details := make(map[string]interface{})
-	if err.Details != nil {
-		for k, v := range err.Details {
-			details[k] = v
-		}
+	fields := logFieldsPool.Get().(*[]Field)
+	defer func() {
+		clear(*fields)
+		*fields = (*fields)[:0]
+		logFieldsPool.Put(fields)
+	}()
+	
+	add := func(key string, value interface{}) {
+		*fields = append(*fields, Field{Key: key, Value: value})
 	}
 	
 	// Add timestamp
-	details["timestamp"] = err.Timestamp
+	add("timestamp", err.Timestamp)
+	
+	// Add the handler's subsystem
+	if err.Component != "" {
+		add("component", err.Component)
+	}
+	
+	// Add wrap site
+	if err.Origin != "" {
+		add("origin", err.Origin)
+	}
+	
+	// Add classification when it differs from the defaults
+	if err.Severity != SeverityError && err.Severity != 0 {
+		add("severity", err.Severity.String())
+	}
+	if err.Category != "" {
+		add("category", err.Category)
+	}
+	
+	// Add the wrapping goroutine, for execution traces
+	if err.Goroutine != 0 {
+		add("goroutine", err.Goroutine)
+	}
+	
+	// Add the incident the error belongs to
+	if err.IncidentID != "" {
+		add("incident_id", err.IncidentID)
+	}
+	
+	// Add the build that produced the error
+	if err.Build != nil {
+		add("build", err.Build.String())
+	}
+	
+	// Add attachment summaries (never their data)
+	if len(err.Attachments) > 0 {
+		summaries := make([]string, len(err.Attachments))
+		for i, a := range err.Attachments {
+			summaries[i] = a.String()
+		}
+		add("attachments", summaries)
+	}
+	
+	// Add the failures of each dependency
+	if len(err.Causes) > 0 {
+		add("causes", causeMessages(err.Causes))
+	}
+	
+	// Add what happened in the request before the error
+	if len(err.Breadcrumbs) > 0 {
+		trail := make([]string, len(err.Breadcrumbs))
+		for i, b := range err.Breadcrumbs {
+			trail[i] = b.String()
+		}
+		add("breadcrumbs", trail)
+	}
+	
+	// Add the wrap trail, innermost layer first
+	if len(err.Trail) > 0 {
+		trail := make([]string, len(err.Trail))
+		for i, entry := range err.Trail {
+			trail[i] = entry.String()
+		}
+		add("trail", trail)
+	}
+	
+	// Add other errors of the same request
+	if len(err.Related) > 0 {
+		add("related_error_ids", err.Related)
+	}
+	
+	// Log with stack trace as separate parameter (not in details), or as
+	// frames in details for log pipelines that index them
+	stackTrace := ""
+	if h.config.StackTraceTargets.has(StackToLogs) {
+		if h.config.StackFormat == StackFormatFrames {
+			if frames := err.PanicFrames(); frames != nil {
+				add("panic_frames", frames)
+			}
+			if frames := err.Frames(); frames != nil {
+				add("stack_frames", frames)
+			}
+		} else {
+			stackTrace = loggedStack(err)
+		}
+	}
+	if logger, ok := h.config.Logger.(FieldLogger); ok {
+		logger.ErrorFields(err.ID, err.Original, err.Context, err.Details, *fields, stackTrace)
+		return
+	}
 	
-	// Log with stack trace as separate parameter (not in details)
-	h.config.Logger.Error(err.ID, err.Original, err.Context, details, err.StackTrace)
+	// Plain Loggers get a copy of the details with the fields merged in
+	merged := make(map[string]interface{}, len(err.Details)+len(*fields))
+	for k, v := range err.Details {
+		merged[k] = v
+	}
+	for _, field := range *fields {
+		merged[field.Key] = field.Value
+	}
+	h.config.Logger.Error(err.ID, err.Original, err.Context, merged, stackTrace)
+}
+
+// logFieldsPool recycles the field slices of logError
+var logFieldsPool = sync.Pool{New: func() interface{} {
+	fields := make([]Field, 0, 16)
+	return &fields
+}}
+
+// Headers of the logged stack trace of recovered panics
+const (
+	panicStackHeader = "panicked at:\n"
+	wrapStackHeader  = "wrapped at:\n"
+)
+
+// loggedStack is the stack trace passed to the Logger: for recovered
+// panics the panic site stack comes first, then the wrap-site stack
+func loggedStack(err *ErrorWithID) string {
+	if err.PanicStack == "" {
+		return err.StackTrace
+	}
+	if err.StackTrace == "" {
+		return panicStackHeader + err.PanicStack
+	}
+	return panicStackHeader + err.PanicStack + wrapStackHeader + err.StackTrace
 }
 
 // safeCallback executes OnError callback with panic recovery
 func (h *Handler) safeCallback(err *ErrorWithID) {
 	defer func() {
 		if r := recover(); r != nil {
-			// Callback panicked, log it but don't crash
-			if h.config.Logger != nil {
-				h.config.Logger.Info("OnError callback panicked: " + fmt.Sprint(r))
-			}
+			// Callback panicked, count and report it but don't crash
+			h.stats.callbackPanics.Add(1)
+			h.callbackFailed(err, fmt.Errorf("%w: %v", ErrCallbackPanic, r))
 		}
 	}()
 	
 	h.config.OnError(err)
 }
 
+// callbackFailed logs an OnError failure and runs OnCallbackError
+func (h *Handler) callbackFailed(err *ErrorWithID, cause error) {
+	if h.config.Logger != nil {
+		h.config.Logger.Info(fmt.Sprintf("%v (error %s)", cause, err.ID))
+	}
+	
+	if h.config.OnCallbackError == nil {
+		return
+	}
+	
+	// The hook must not take the handler down either
+	defer func() {
+		if r := recover(); r != nil && h.config.Logger != nil {
+			h.config.Logger.Info("OnCallbackError hook panicked: " + fmt.Sprint(r))
+		}
+	}()
+	h.config.OnCallbackError(err, cause)
+}
+
 // Config returns current handler configuration (read-only)
 func (h *Handler) Config() Config {
 	return h.config
@@ -0,0 +1,96 @@
+package errorid
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HoneycombExporter sends errors as events to a Honeycomb dataset
+// It is a Notifier, so it can be a Dispatcher route or an OnError callback:
+//
+//	honeycomb := &errorid.HoneycombExporter{APIKey: key, Dataset: "errors", ServiceName: "api"}
+//	errorid.Configure(errorid.Config{OnError: honeycomb.OnError, AsyncCallback: true})
+type HoneycombExporter struct {
+	APIKey      string
+	Dataset     string
+	ServiceName string       // Sent as service.name
+	APIURL      string       // Default https://api.honeycomb.io
+	HTTPClient  *http.Client // Default http.DefaultClient
+}
+
+// Notify sends err as one event
+func (e *HoneycombExporter) Notify(ctx context.Context, err *ErrorWithID) error {
+	api := e.APIURL
+	if api == "" {
+		api = "https://api.honeycomb.io"
+	}
+	
+	header := http.Header{}
+	header.Set("X-Honeycomb-Team", e.APIKey)
+	header.Set("X-Honeycomb-Event-Time", time.Unix(err.Timestamp, 0).UTC().Format(time.RFC3339))
+	
+	return postJSON(ctx, e.HTTPClient, api+"/1/events/"+url.PathEscape(e.Dataset), header, e.event(err))
+}
+
+// OnError is a Config.OnError callback; delivery errors are dropped
+func (e *HoneycombExporter) OnError(err *ErrorWithID) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	
+	e.Notify(ctx, err)
+}
+
+// event flattens err into Honeycomb fields
+// Details become "detail.<key>" columns
+func (e *HoneycombExporter) event(err *ErrorWithID) map[string]interface{} {
+	event := map[string]interface{}{
+		"error":             true,
+		"error.id":          err.ID,
+		"error.message":     errorMessage(err),
+		"error.type":        errorType(err.Original),
+		"error.context":     err.Context,
+		"error.fingerprint": err.Fingerprint(),
+		"error.severity":    err.Severity.String(),
+	}
+	
+	if e.ServiceName != "" {
+		event["service.name"] = e.ServiceName
+	}
+	if code := ErrorCode(err); code != "" {
+		event["error.code"] = code
+	}
+	if err.Category != "" {
+		event["error.category"] = err.Category
+	}
+	if err.Component != "" {
+		event["error.component"] = err.Component
+	}
+	if err.Origin != "" {
+		event["error.origin"] = err.Origin
+	}
+	if err.StackTrace != "" {
+		event["error.stack"] = err.StackTrace
+	}
+	if err.PanicStack != "" {
+		event["error.panic_stack"] = err.PanicStack
+	}
+	if err.Build != nil {
+		event["service.version"] = err.Build.String()
+	}
+	
+	for k, v := range err.Details {
+		event["detail."+k] = v
+	}
+	
+	return event
+}
+
+// errorMessage is the original error's message, without ID or context
+func errorMessage(err *ErrorWithID) string {
+	if err.Original == nil {
+		return ""
+	}
+	return err.Original.Error()
+}
@@ -0,0 +1,69 @@
+package errorid
+
+// HTTPError is a fully specified API error: status, code and public
+// message together with its ID, built in one call instead of combining a
+// Definition, WrapWithDetails and WriteError:
+//
+//	if !found {
+//		errorid.WriteError(w, errorid.NewHTTPError(http.StatusNotFound, "ORDER_NOT_FOUND", "order not found", err).ErrorWithID)
+//		return
+//	}
+//
+// The fields are read when the response is written, so they may still be
+// changed after creation. The embedded ErrorWithID carries them in its
+// chain (StatusCoder, Coder, PublicMessager), so WriteError, middleware
+// and errors.As all see the same values
+type HTTPError struct {
+	Status        int
+	Code          string
+	PublicMessage string
+	*ErrorWithID
+}
+
+// NewHTTPError creates an HTTPError with a fresh ID using the default
+// handler. cause may be nil; the error message is then PublicMessage
+func NewHTTPError(status int, code, message string, cause error) *HTTPError {
+	return defaultFor(2).newHTTPError(status, code, message, cause)
+}
+
+// NewHTTPError creates an HTTPError with a fresh ID using handler h
+func (h *Handler) NewHTTPError(status int, code, message string, cause error) *HTTPError {
+	return h.newHTTPError(status, code, message, cause)
+}
+
+// newHTTPError wraps an httpErrorCause pointing back at the HTTPError
+func (h *Handler) newHTTPError(status int, code, message string, cause error) *HTTPError {
+	e := &HTTPError{Status: status, Code: code, PublicMessage: message}
+	e.ErrorWithID = h.wrap(nil, &httpErrorCause{e: e, cause: cause}, "", nil)
+	return e
+}
+
+// Unwrap returns the embedded ErrorWithID
+func (e *HTTPError) Unwrap() error {
+	return e.ErrorWithID
+}
+
+// httpErrorCause is the Original of an HTTPError's ErrorWithID, exposing
+// its current fields to the chain
+type httpErrorCause struct {
+	e     *HTTPError
+	cause error
+}
+
+func (c *httpErrorCause) Error() string {
+	if c.cause != nil {
+		return c.cause.Error()
+	}
+	return c.e.PublicMessage
+}
+
+func (c *httpErrorCause) Unwrap() error { return c.cause }
+
+// HTTPStatus implements StatusCoder
+func (c *httpErrorCause) HTTPStatus() int { return c.e.Status }
+
+// ErrorCode implements Coder
+func (c *httpErrorCause) ErrorCode() string { return c.e.Code }
+
+// PublicMessage implements PublicMessager
+func (c *httpErrorCause) PublicMessage() string { return c.e.PublicMessage }
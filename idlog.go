@@ -0,0 +1,130 @@
+package errorid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// IDRecord is one line of an IDLog: an ID that was handed out, when, and
+// the fingerprint of its error
+type IDRecord struct {
+	ID          string
+	Timestamp   int64
+	Fingerprint string
+}
+
+// IDLog is a write-ahead log of error IDs only: one fsynced
+// "ID TIMESTAMP FINGERPRINT" line per error, appended before sampling,
+// interceptors, logging or storage (Config.IDLog). Any ID a customer
+// reports can then be confirmed as genuine and placed in time, even with
+// no Store and logs sampled or lost. Nothing else about the error is
+// written, so it is safe to keep for long periods
+//
+//	idLog, err := errorid.OpenIDLog("/var/lib/app/error-ids.log")
+//	defer idLog.Close()
+//	errorid.Configure(errorid.Config{IDLog: idLog})
+//
+// Every append is synced to disk: on slow disks, budget for an fsync per
+// error. Rotate the file with the application stopped, or open a new
+// IDLog and swap handlers
+type IDLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenIDLog appends to the ID log at path, creating it if needed
+func OpenIDLog(path string) (*IDLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &IDLog{path: path, file: file}, nil
+}
+
+// Append writes and syncs the record of err
+func (l *IDLog) Append(err *ErrorWithID) error {
+	line := fmt.Sprintf("%s %d %s\n", err.ID, err.Timestamp, err.Fingerprint())
+	
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	if _, writeErr := io.WriteString(l.file, line); writeErr != nil {
+		return writeErr
+	}
+	return l.file.Sync()
+}
+
+// Lookup scans the log for id
+// Returns false if the ID is not in the log
+func (l *IDLog) Lookup(id string) (IDRecord, bool, error) {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return IDRecord{}, false, err
+	}
+	defer file.Close()
+	
+	return LookupIDLog(file, id)
+}
+
+// Close closes the log file
+func (l *IDLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	return l.file.Close()
+}
+
+// LookupIDLog scans an ID log (e.g. an archived one) for id
+// Returns false if the ID is not in the log
+func LookupIDLog(log io.Reader, id string) (IDRecord, bool, error) {
+	scanner := bufio.NewScanner(log)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, id+" ") {
+			continue
+		}
+	
+		var record IDRecord
+		if _, err := fmt.Sscanf(line, "%s %d %s", &record.ID, &record.Timestamp, &record.Fingerprint); err != nil {
+			return IDRecord{}, false, fmt.Errorf("errorid: malformed ID log line %q: %w", line, err)
+		}
+		return record, true, nil
+	}
+	return IDRecord{}, false, scanner.Err()
+}
+
+// ConfirmID looks id up in Config.IDLog, to tell a genuine ID reported by
+// a customer from a mistyped or made-up one, and when it was issued
+// Returns ErrNotFound if there is no ID log or no such ID
+func (h *Handler) ConfirmID(id string) (IDRecord, error) {
+	if h.config.IDLog == nil {
+		return IDRecord{}, ErrNotFound
+	}
+	
+	record, found, err := h.config.IDLog.Lookup(id)
+	if err != nil {
+		return IDRecord{}, err
+	}
+	if !found {
+		return IDRecord{}, ErrNotFound
+	}
+	return record, nil
+}
+
+// logID appends err to Config.IDLog, logging failures
+func (h *Handler) logID(err *ErrorWithID) {
+	if h.config.IDLog == nil || err.ID == "" {
+		return
+	}
+	if appendErr := h.config.IDLog.Append(err); appendErr != nil {
+		h.stats.idLogFailures.Add(1)
+		if h.config.Logger != nil {
+			h.config.Logger.Info(fmt.Sprintf("id log: appending %s failed: %v", err.ID, appendErr))
+		}
+	}
+}
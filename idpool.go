@@ -0,0 +1,100 @@
+package errorid
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// idPoolBatch is how many suffixes the filler reads from crypto/rand at once
+const idPoolBatch = 64
+
+// idPoolRetry is how long the filler waits after a crypto/rand failure
+const idPoolRetry = time.Second
+
+// IDPool pre-generates the random part of error IDs in the background so
+// hot paths don't pay a crypto/rand read per wrap. The date is still taken
+// (in Config.TimeLocation) when an ID is handed out. While crypto/rand fails
+// the pool stops filling, so IDs are generated inline (with IDFallback, and
+// counted) once the buffer is drained. Use it as Config.IDPool:
+//
+//	pool := errorid.NewIDPool(4096)
+//	defer pool.Close()
+//	handler := errorid.New(errorid.Config{IDPool: pool})
+type IDPool struct {
+	suffixes  chan string
+	done      chan struct{}
+	stopped   chan struct{} // closed when fill returns
+	closeOnce sync.Once
+}
+
+// NewIDPool starts a pool buffering up to size IDs (default 1024)
+func NewIDPool(size int) *IDPool {
+	if size <= 0 {
+		size = 1024
+	}
+	
+	p := &IDPool{
+		suffixes: make(chan string, size),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go p.fill()
+	return p
+}
+
+// Next returns an ID in the GenerateErrorID format, dated in local time
+// When the buffer is empty (burst, or pool closed) it generates one inline
+func (p *IDPool) Next() string {
+	now := time.Now()
+	if suffix, ok := p.suffix(); ok {
+		return "ERR-" + now.Format("20060102") + "-" + suffix
+	}
+	return generateErrorID(now, nil, nil)
+}
+
+// suffix takes a buffered random part, false if the buffer is empty
+func (p *IDPool) suffix() (string, bool) {
+	select {
+	case suffix := <-p.suffixes:
+		return suffix, true
+	default:
+		return "", false
+	}
+}
+
+// Close stops the background filler and waits for it to exit
+// Next keeps working, generating IDs inline once the buffer is drained
+func (p *IDPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+	<-p.stopped
+}
+
+// fill keeps the buffer full until Close
+func (p *IDPool) fill() {
+	defer close(p.stopped)
+	
+	buf := make([]byte, 3*idPoolBatch)
+	for {
+		if _, err := randRead(buf); err != nil {
+			// Leave fallback IDs to the inline generator
+			select {
+			case <-time.After(idPoolRetry):
+				continue
+			case <-p.done:
+				return
+			}
+		}
+		
+		for i := 0; i < idPoolBatch; i++ {
+			suffix := hex.EncodeToString(buf[3*i : 3*i+3])
+			select {
+			case p.suffixes <- suffix:
+			case <-p.done:
+				return
+			}
+		}
+	}
+}
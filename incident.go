@@ -0,0 +1,85 @@
+package errorid
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// incidentSweepSize is the number of tracked fingerprints above which
+// quiet ones are pruned
+const incidentSweepSize = 1024
+
+// incidentTracker detects bursts of errors sharing a fingerprint and
+// assigns them a common incident ID
+type incidentTracker struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	bursts    map[string]*burst
+}
+
+// burst tracks recent occurrences of one fingerprint
+type burst struct {
+	times    []time.Time // occurrences within the window, oldest first
+	incident string      // open incident, if any
+	last     time.Time
+}
+
+func newIncidentTracker(threshold int, window time.Duration) *incidentTracker {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &incidentTracker{
+		threshold: threshold,
+		window:    window,
+		bursts:    make(map[string]*burst),
+	}
+}
+
+// observe records an occurrence of fingerprint at now and returns the
+// incident ID it belongs to, or "" if there is no storm
+// An incident opens at the threshold-th occurrence within the window and
+// stays open until the fingerprint has been quiet for a whole window
+func (t *incidentTracker) observe(fingerprint string, now time.Time) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	b := t.bursts[fingerprint]
+	if b == nil {
+		if len(t.bursts) >= incidentSweepSize {
+			t.sweep(now)
+		}
+		b = &burst{}
+		t.bursts[fingerprint] = b
+	}
+	
+	if b.incident != "" && now.Sub(b.last) > t.window {
+		b.incident = ""
+	}
+	b.last = now
+	
+	// Keep only occurrences inside the window
+	cutoff := now.Add(-t.window)
+	kept := b.times[:0]
+	for _, at := range b.times {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	b.times = append(kept, now)
+	
+	if b.incident == "" && len(b.times) >= t.threshold {
+		b.incident = "INC" + strings.TrimPrefix(generateErrorID(now, nil, nil), "ERR")
+	}
+	return b.incident
+}
+
+// sweep drops fingerprints that have been quiet for a window
+func (t *incidentTracker) sweep(now time.Time) {
+	for fingerprint, b := range t.bursts {
+		if now.Sub(b.last) > t.window {
+			delete(t.bursts, fingerprint)
+		}
+	}
+}
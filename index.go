@@ -0,0 +1,159 @@
+package errorid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// IndexEntry is one line of an error index: where an error's log entry
+// starts in the main log file
+type IndexEntry struct {
+	ID          string
+	Timestamp   int64
+	Fingerprint string
+	Offset      int64 // Byte offset of the entry in the log file
+}
+
+// IndexLogger is a DefaultLogger that also writes a compact sidecar index
+// ("ID TIMESTAMP FINGERPRINT OFFSET" per error), so an ID can be found in
+// large or rotated log archives without scanning them. Rotate the log and
+// its index together
+//
+//	logger, err := errorid.OpenIndexLogger("/var/log/app/errors.log") // + errors.log.idx
+//	defer logger.Close()
+//	errorid.Configure(errorid.Config{Logger: logger})
+type IndexLogger struct {
+	mu     sync.Mutex
+	logger *DefaultLogger
+	log    *countingWriter
+	index  io.Writer
+	closer []io.Closer
+}
+
+// countingWriter tracks the offset of the next byte written
+type countingWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.offset += int64(n)
+	return n, err
+}
+
+// NewIndexLogger logs to log, indexing errors into index
+// offset is the current size of log when appending to an existing file
+func NewIndexLogger(log io.Writer, offset int64, index io.Writer) *IndexLogger {
+	counting := &countingWriter{w: log, offset: offset}
+	return &IndexLogger{
+		logger: NewDefaultLogger(counting),
+		log:    counting,
+		index:  index,
+	}
+}
+
+// OpenIndexLogger appends to the log file at path and its index at
+// path + ".idx", creating them if needed
+func OpenIndexLogger(path string) (*IndexLogger, error) {
+	logFile, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	
+	info, err := logFile.Stat()
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	
+	indexFile, err := os.OpenFile(path+".idx", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	
+	l := NewIndexLogger(logFile, info.Size(), indexFile)
+	l.closer = []io.Closer{logFile, indexFile}
+	return l, nil
+}
+
+// Error logs the error and appends its index entry
+func (l *IndexLogger) Error(errorID string, err error, context string, details map[string]interface{}, stackTrace string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	offset := l.log.offset
+	l.logger.Error(errorID, err, context, details, stackTrace)
+	
+	timestamp, _ := details["timestamp"].(int64)
+	origin, _ := details["origin"].(string)
+	fmt.Fprintf(l.index, "%s %d %s %d\n", errorID, timestamp, fingerprint(context, err, origin, stackTrace), offset)
+}
+
+// ErrorFields logs the error and appends its index entry, without
+// copying details
+func (l *IndexLogger) ErrorFields(errorID string, err error, context string, details map[string]interface{}, fields []Field, stackTrace string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	offset := l.log.offset
+	l.logger.ErrorFields(errorID, err, context, details, fields, stackTrace)
+	
+	var timestamp int64
+	var origin string
+	for _, field := range fields {
+		switch field.Key {
+		case "timestamp":
+			timestamp, _ = field.Value.(int64)
+		case "origin":
+			origin, _ = field.Value.(string)
+		}
+	}
+	fmt.Fprintf(l.index, "%s %d %s %d\n", errorID, timestamp, fingerprint(context, err, origin, stackTrace), offset)
+}
+
+// Info logs an informational message (not indexed)
+func (l *IndexLogger) Info(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	l.logger.Info(msg)
+}
+
+// Close closes files opened by OpenIndexLogger
+func (l *IndexLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	var firstErr error
+	for _, c := range l.closer {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// LookupIndex scans an index for id
+// Returns false if the ID is not in the index
+func LookupIndex(index io.Reader, id string) (IndexEntry, bool, error) {
+	scanner := bufio.NewScanner(index)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, id+" ") {
+			continue
+		}
+		
+		var entry IndexEntry
+		if _, err := fmt.Sscanf(line, "%s %d %s %d", &entry.ID, &entry.Timestamp, &entry.Fingerprint, &entry.Offset); err != nil {
+			return IndexEntry{}, false, fmt.Errorf("errorid: malformed index line %q: %w", line, err)
+		}
+		return entry, true, nil
+	}
+	return IndexEntry{}, false, scanner.Err()
+}
@@ -0,0 +1,60 @@
+package errorid
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/netip"
+)
+
+// MaskIP anonymizes a client address for Config.RemoteAddrFilter
+// IPv4 keeps the first three octets (/24), IPv6 the first 48 bits (/48)
+// The port is dropped. Unparseable addresses become ""
+func MaskIP(addr string) string {
+	ip, ok := parseRemoteIP(addr)
+	if !ok {
+		return ""
+	}
+	
+	bits := 48
+	if ip.Is4() {
+		bits = 24
+	}
+	
+	prefix, err := ip.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.Addr().String()
+}
+
+// HashIP returns a Config.RemoteAddrFilter replacing addresses with a keyed
+// hash (HMAC-SHA256, first 16 hex chars). Errors from the same client still
+// correlate, but the address can't be recovered without the key
+// Rotate the key to limit how long hashes stay linkable
+func HashIP(key []byte) func(addr string) string {
+	return func(addr string) string {
+		ip, ok := parseRemoteIP(addr)
+		if !ok {
+			return ""
+		}
+		
+		mac := hmac.New(sha256.New, key)
+		mac.Write(ip.AsSlice())
+		return hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+}
+
+// parseRemoteIP extracts the IP from "host:port" or a bare IP
+func parseRemoteIP(addr string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
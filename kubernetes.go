@@ -0,0 +1,56 @@
+package errorid
+
+import (
+	"os"
+	"strings"
+)
+
+// serviceAccountNamespaceFile holds the pod's namespace in every pod that
+// mounts a service account token
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// KubernetesDetails returns pod placement details for Config.DefaultDetails:
+//
+//	k8s_pod        POD_NAME, or HOSTNAME inside a cluster
+//	k8s_namespace  POD_NAMESPACE, or the service account namespace file
+//	k8s_node       NODE_NAME
+//	k8s_image      CONTAINER_IMAGE
+//
+// Expose the variables with the downward API (fieldRef metadata.name,
+// metadata.namespace, spec.nodeName); the image has no downward-API field,
+// so set CONTAINER_IMAGE in the pod spec. Missing values are left out,
+// outside Kubernetes the map is empty
+func KubernetesDetails() map[string]interface{} {
+	return kubernetesDetails(os.Getenv, os.ReadFile)
+}
+
+// kubernetesDetails is KubernetesDetails with injectable sources
+func kubernetesDetails(getenv func(string) string, readFile func(string) ([]byte, error)) map[string]interface{} {
+	details := make(map[string]interface{})
+	inCluster := getenv("KUBERNETES_SERVICE_HOST") != ""
+	
+	pod := getenv("POD_NAME")
+	if pod == "" && inCluster {
+		pod = getenv("HOSTNAME") // defaults to the pod name
+	}
+	
+	namespace := getenv("POD_NAMESPACE")
+	if namespace == "" && inCluster {
+		if data, err := readFile(serviceAccountNamespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(data))
+		}
+	}
+	
+	for key, value := range map[string]string{
+		"k8s_pod":       pod,
+		"k8s_namespace": namespace,
+		"k8s_node":      getenv("NODE_NAME"),
+		"k8s_image":     getenv("CONTAINER_IMAGE"),
+	} {
+		if value != "" {
+			details[key] = value
+		}
+	}
+	
+	return details
+}
@@ -0,0 +1,140 @@
+package errorid
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxLabelFingerprints bounds the fingerprints cached per ContextLabel
+const maxLabelFingerprints = 64
+
+// contextLabels is the registry of declared contexts, by name
+var (
+	contextLabelsMu sync.RWMutex
+	contextLabels   = make(map[string]*ContextLabel)
+)
+
+// ContextLabel is a wrap context declared once, as a package variable,
+// instead of a string literal at every wrap site:
+//
+//	var ctxCharge = errorid.NewContextLabel("payments.charge")
+//
+//	return ctxCharge.Wrap(err)
+//
+// Its metric label is computed once and fingerprints are cached per
+// error kind and site, so wraps skip most of the hashing. Declared
+// labels are listed by ContextLabels, e.g. to build dashboards
+type ContextLabel struct {
+	name   string
+	metric string
+	
+	fingerprints sync.Map // fingerprintKey -> string
+	cached       atomic.Int32
+}
+
+// fingerprintKey is the per-label part of a fingerprint
+type fingerprintKey struct {
+	kind string
+	site string
+}
+
+// NewContextLabel declares the context name. Declaring the same name
+// again returns the same label. Panics if name is empty
+func NewContextLabel(name string) *ContextLabel {
+	if strings.TrimSpace(name) == "" {
+		panic("errorid: empty context label")
+	}
+	
+	contextLabelsMu.Lock()
+	defer contextLabelsMu.Unlock()
+	if l, ok := contextLabels[name]; ok {
+		return l
+	}
+	l := &ContextLabel{name: name, metric: metricLabel(name)}
+	contextLabels[name] = l
+	return l
+}
+
+// ContextLabels returns the declared context labels, sorted by name
+func ContextLabels() []*ContextLabel {
+	contextLabelsMu.RLock()
+	defer contextLabelsMu.RUnlock()
+	
+	labels := make([]*ContextLabel, 0, len(contextLabels))
+	for _, l := range contextLabels {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+	return labels
+}
+
+// String returns the context name
+func (l *ContextLabel) String() string {
+	return l.name
+}
+
+// MetricLabel returns the name as a Prometheus-safe label value
+// ("payments.charge" -> "payments_charge"), or "unlabeled" for nil
+func (l *ContextLabel) MetricLabel() string {
+	if l == nil {
+		return "unlabeled"
+	}
+	return l.metric
+}
+
+// Label returns the ContextLabel e was wrapped with, or nil
+func (e *ErrorWithID) Label() *ContextLabel {
+	return e.label
+}
+
+// Wrap wraps err with the label as context using the default handler
+func (l *ContextLabel) Wrap(err error) *ErrorWithID {
+	return defaultFor(2).wrapLabel(nil, err, l.name, l, nil)
+}
+
+// WrapContext is Wrap with a request context (see WrapContext)
+func (l *ContextLabel) WrapContext(ctx context.Context, err error) *ErrorWithID {
+	return defaultFor(2).wrapLabel(ctx, err, l.name, l, nil)
+}
+
+// WrapLabel wraps err with a declared context
+func (h *Handler) WrapLabel(err error, label *ContextLabel) *ErrorWithID {
+	return h.wrapLabel(nil, err, label.name, label, nil)
+}
+
+// WrapLabelContext is WrapLabel with a request context
+func (h *Handler) WrapLabelContext(ctx context.Context, err error, label *ContextLabel) *ErrorWithID {
+	return h.wrapLabel(ctx, err, label.name, label, nil)
+}
+
+// fingerprint returns the fingerprint of an error of the label, cached
+// for the first maxLabelFingerprints kind and site pairs
+func (l *ContextLabel) fingerprint(kind, site string) string {
+	key := fingerprintKey{kind: kind, site: site}
+	if fp, ok := l.fingerprints.Load(key); ok {
+		return fp.(string)
+	}
+	
+	fp := fingerprintHash(l.name, kind, site)
+	if l.cached.Load() < maxLabelFingerprints && l.cached.Add(1) <= maxLabelFingerprints {
+		l.fingerprints.Store(key, fp)
+	}
+	return fp
+}
+
+// metricLabel lowercases name and replaces characters other than letters,
+// digits and underscores with underscores
+func metricLabel(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, name)
+}
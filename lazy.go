@@ -0,0 +1,93 @@
+package errorid
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultLazyTimeout bounds the evaluation of an error's lazy details
+const defaultLazyTimeout = 100 * time.Millisecond
+
+// Lazy is a detail value computed only if the error is reported (logged
+// and passed to OnError after sampling and other interceptors), or shown
+// in a response, for details that are expensive to build:
+//
+//	errorid.WrapWithDetails(err, "sync cart", map[string]interface{}{
+//		"cart": errorid.Lazy(func() interface{} { return cart.Dump() }),
+//	})
+//
+// Plain func() interface{} values are treated the same
+type Lazy func() interface{}
+
+// lazyOf returns v as a lazy detail function, if it is one
+func lazyOf(v interface{}) (func() interface{}, bool) {
+	switch f := v.(type) {
+	case Lazy:
+		return f, f != nil
+	case func() interface{}:
+		return f, f != nil
+	}
+	return nil, false
+}
+
+// resolveDetails returns details with lazy values evaluated, or details
+// itself if it has none. Lazy values run concurrently; those still running
+// after Config.LazyDetailTimeout (default 100ms) are given up on
+func (h *Handler) resolveDetails(details map[string]interface{}) map[string]interface{} {
+	lazies := make(map[string]func() interface{})
+	for k, v := range details {
+		if f, ok := lazyOf(v); ok {
+			lazies[k] = f
+		}
+	}
+	if len(lazies) == 0 {
+		return details
+	}
+	
+	timeout := h.config.LazyDetailTimeout
+	if timeout <= 0 {
+		timeout = defaultLazyTimeout
+	}
+	
+	// Copy: the map may be the caller's
+	resolved := make(map[string]interface{}, len(details))
+	for k, v := range details {
+		resolved[k] = v
+	}
+	for k := range lazies {
+		resolved[k] = fmt.Sprintf("<timed out after %v>", timeout)
+	}
+	
+	type result struct {
+		key   string
+		value interface{}
+	}
+	results := make(chan result, len(lazies))
+	for k, f := range lazies {
+		go func(k string, f func() interface{}) {
+			results <- result{k, callLazy(f)}
+		}(k, f)
+	}
+	
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for range lazies {
+		select {
+		case r := <-results:
+			resolved[r.key] = r.value
+		case <-timer.C:
+			return resolved
+		}
+	}
+	return resolved
+}
+
+// callLazy evaluates f, turning a panic into a placeholder value
+func callLazy(f func() interface{}) (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("<panic: %v>", r)
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,159 @@
+package errorid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fatalFlushTimeout bounds how long Fatal waits for async callbacks
+const fatalFlushTimeout = 5 * time.Second
+
+// osExit is os.Exit, replaceable in tests
+var osExit = os.Exit
+
+// syncer is implemented by buffered loggers (zap, *os.File, ...)
+type syncer interface {
+	Sync() error
+}
+
+// Flush waits for running async OnError callbacks and buffered store
+// writes (WriteBehindStore), and syncs the logger if it has a Sync method
+// Returns ctx.Err() if ctx ends first
+func (h *Handler) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(done)
+	}()
+	
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	
+	if f, ok := h.config.Store.(flusher); ok {
+		if err := f.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	
+	if s, ok := h.config.Logger.(syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close shuts the handler down for server shutdown sequences: later async
+// OnError calls are dropped, running ones are waited for (until ctx ends),
+// the logger is synced, telemetry sends its final report, and the store
+// and logger are closed if they have a Close method
+// Wrapping and logging keep working. Calls after the first return nil
+func (h *Handler) Close(ctx context.Context) error {
+	if h.closed.Swap(true) {
+		return nil
+	}
+	
+	err := h.Flush(ctx)
+	if h.telemetry != nil {
+		err = errors.Join(err, h.telemetry.close(ctx))
+	}
+	if h.config.Store != nil {
+		err = errors.Join(err, closeStore(ctx, h.config.Store))
+	}
+	if closer, ok := h.config.Logger.(io.Closer); ok {
+		err = errors.Join(err, closer.Close())
+	}
+	return err
+}
+
+// fatalError marks the error passed to Fatal as critical
+type fatalError struct {
+	err error
+}
+
+func (e *fatalError) Error() string           { return e.err.Error() }
+func (e *fatalError) Unwrap() error           { return e.err }
+func (e *fatalError) ErrorSeverity() Severity { return SeverityCritical }
+
+// Fatal wraps err as a critical error using the default handler, then
+// flushes and exits; see Handler.Fatal
+func Fatal(err error, context string) {
+	defaultFor(2).fatal(err, context)
+}
+
+// Fatal replaces log.Fatal: it wraps err (a nil err becomes "fatal"),
+// logs it, waits up to 5s for async OnError callbacks, syncs the logger,
+// writes a crash file to Config.CrashDir if set, and exits with status 1
+func (h *Handler) Fatal(err error, context string) {
+	h.fatal(err, context)
+}
+
+// fatal implements Fatal for both APIs, keeping the caller frame depth equal
+func (h *Handler) fatal(err error, label string) {
+	if err == nil {
+		err = errors.New("fatal")
+	}
+	
+	wrapped := h.wrap(nil, &fatalError{err: err}, label, nil)
+	if wrapped.StackTrace == "" {
+		wrapped.StackTrace = captureStackTrace(h.callerSkip)
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+	h.Flush(ctx)
+	cancel()
+	
+	if h.config.CrashDir != "" {
+		if writeErr := h.writeCrashFile(wrapped); writeErr != nil && h.config.Logger != nil {
+			h.config.Logger.Info("writing crash file failed: " + writeErr.Error())
+		}
+	}
+	
+	osExit(1)
+}
+
+// crashReport is the content of a crash file
+type crashReport struct {
+	ErrorID    string                 `json:"error_id"`
+	Message    string                 `json:"message"`
+	Context    string                 `json:"context,omitempty"`
+	Timestamp  int64                  `json:"timestamp"`
+	Origin     string                 `json:"origin,omitempty"`
+	Build      string                 `json:"build,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+	StackTrace string                 `json:"stack_trace"`
+	PanicStack string                 `json:"panic_stack,omitempty"`
+}
+
+// writeCrashFile writes err to CrashDir/<ID>.json
+func (h *Handler) writeCrashFile(err *ErrorWithID) error {
+	report := crashReport{
+		ErrorID:    err.ID,
+		Message:    errorMessage(err),
+		Context:    err.Context,
+		Timestamp:  err.Timestamp,
+		Origin:     err.Origin,
+		Details:    err.Details,
+		StackTrace: err.StackTrace,
+		PanicStack: err.PanicStack,
+	}
+	if err.Build != nil {
+		report.Build = err.Build.String()
+	}
+	
+	data, marshalErr := json.MarshalIndent(report, "", "  ")
+	if marshalErr != nil {
+		return marshalErr
+	}
+	
+	if mkdirErr := os.MkdirAll(h.config.CrashDir, 0o755); mkdirErr != nil {
+		return mkdirErr
+	}
+	return os.WriteFile(filepath.Join(h.config.CrashDir, err.ID+".json"), data, 0o644)
+}
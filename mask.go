@@ -0,0 +1,159 @@
+package errorid
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldMask shapes the error a sink receives (Route.Mask), so each channel
+// gets only what it may see: e.g. an external webhook no PII, while an
+// error tracker gets everything. The zero FieldMask passes all fields
+//
+//	errorid.Route{Notifier: webhook, Mask: &errorid.FieldMask{
+//		IncludeDetails: []string{"order_id", "status"},
+//		OmitStacks:     true,
+//		OmitRequest:    true,
+//		MaxBytes:       4 << 10,
+//	}}
+type FieldMask struct {
+	IncludeDetails []string // Detail keys kept, path.Match globs, case-insensitive (empty = all)
+	ExcludeDetails []string // Detail keys dropped, even if included
+	OmitStacks     bool     // Drop StackTrace and PanicStack
+	OmitRequest    bool     // Drop the captured Request, Breadcrumbs and Attachments
+	
+	// MaxBytes bounds the message, stacks and JSON-encoded details sent
+	// (0 = no limit). Stacks are cut first, then the largest details are
+	// dropped, their keys listed under the "_truncated" detail
+	MaxBytes int
+}
+
+// Apply returns a shaped copy of err; err itself is unchanged. The copy
+// keeps err's Fingerprint, even without its stack
+func (m *FieldMask) Apply(err *ErrorWithID) *ErrorWithID {
+	if m == nil {
+		return err
+	}
+	
+	shaped := *err
+	shaped.fingerprint = err.Fingerprint()
+	shaped.Details = m.details(err.Details)
+	if m.OmitStacks {
+		shaped.StackTrace, shaped.PanicStack = "", ""
+	}
+	if m.OmitRequest {
+		shaped.Request, shaped.Breadcrumbs, shaped.Attachments = nil, nil, nil
+	}
+	if m.MaxBytes > 0 {
+		m.fit(&shaped)
+	}
+	return &shaped
+}
+
+// details returns the details passing the include and exclude globs
+func (m *FieldMask) details(details map[string]interface{}) map[string]interface{} {
+	if len(details) == 0 || (len(m.IncludeDetails) == 0 && len(m.ExcludeDetails) == 0) {
+		return details
+	}
+	
+	kept := make(map[string]interface{}, len(details))
+	for key, value := range details {
+		lower := strings.ToLower(key)
+		if len(m.IncludeDetails) > 0 && !matchAny(m.IncludeDetails, lower) {
+			continue
+		}
+		if matchAny(m.ExcludeDetails, lower) {
+			continue
+		}
+		kept[key] = value
+	}
+	return kept
+}
+
+// matchAny reports whether key matches one of globs, compared lowercase
+func matchAny(globs []string, key string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(strings.ToLower(glob), key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fit cuts err's stacks, then drops its largest details, until it is
+// within MaxBytes
+func (m *FieldMask) fit(err *ErrorWithID) {
+	sizes := make(map[string]int, len(err.Details))
+	over := -m.MaxBytes
+	if err.Original != nil {
+		over += len(err.Original.Error())
+	}
+	over += len(err.StackTrace) + len(err.PanicStack)
+	for key, value := range err.Details {
+		sizes[key] = len(key) + detailSize(value)
+		over += sizes[key]
+	}
+	if over <= 0 {
+		return
+	}
+	
+	for _, stack := range []*string{&err.PanicStack, &err.StackTrace} {
+		cut := min(over, len(*stack))
+		*stack = cutBytes(*stack, len(*stack)-cut)
+		over -= cut
+	}
+	if over <= 0 {
+		return
+	}
+	
+	keys := make([]string, 0, len(sizes))
+	for key := range sizes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if sizes[keys[i]] != sizes[keys[j]] {
+			return sizes[keys[i]] > sizes[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	
+	details := make(map[string]interface{}, len(err.Details))
+	for key, value := range err.Details {
+		details[key] = value
+	}
+	var dropped []string
+	for _, key := range keys {
+		if over <= 0 {
+			break
+		}
+		delete(details, key)
+		dropped = append(dropped, key)
+		over -= sizes[key]
+	}
+	sort.Strings(dropped)
+	details["_truncated"] = dropped
+	err.Details = details
+}
+
+// detailSize is the JSON size of a detail value
+func detailSize(value interface{}) int {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return len(fmt.Sprint(value))
+	}
+	return len(data)
+}
+
+// cutBytes shortens s to at most n bytes without splitting a character
+func cutBytes(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -1,78 +1,363 @@
 package errorid
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // ErrorResponse is the JSON structure returned to clients
 type ErrorResponse struct {
-	ErrorID   string `json:"error_id"`
-	Message   string `json:"message"`
-	Timestamp int64  `json:"timestamp"`
+	ErrorID     string                 `json:"error_id"`
+	Message     string                 `json:"message"`
+	Code        string                 `json:"code,omitempty"` // ErrorCode of the error chain
+	IncidentID  string                 `json:"incident_id,omitempty"`
+	Retryable   *bool                  `json:"retryable,omitempty"`           // Set when the error has a RetryHint
+	RetryAfter  int64                  `json:"retry_after_seconds,omitempty"` // Suggested wait before retrying
+	Timestamp   int64                  `json:"timestamp"`
+	Time        string                 `json:"time,omitempty"` // RFC 3339 in Config.TimeLocation
+	Details     map[string]interface{} `json:"details,omitempty"`
+	StackTrace  string                 `json:"stack_trace,omitempty"`
+	PanicStack  string                 `json:"panic_stack,omitempty"`
+	StackFrames []StackFrame           `json:"stack_frames,omitempty"` // StackFormatFrames
+	PanicFrames []StackFrame           `json:"panic_frames,omitempty"` // StackFormatFrames
+	Related     []string               `json:"related_error_ids,omitempty"`
+	Causes      map[string]string      `json:"causes,omitempty"`      // Failures by dependency (WithCauses), from ResponseDetailMessage
+	Correlation map[string]string      `json:"correlation,omitempty"` // Config.EchoHeaders
+	
+	// Extensions are extra top-level fields (Config.ResponseExtensions)
+	Extensions map[string]interface{} `json:"-"`
 }
 
 // RecoveryMiddleware recovers from panics and returns error ID to client
 // Uses the default singleton handler
 func RecoveryMiddleware(next http.Handler) http.Handler {
-	return Default().RecoveryMiddleware(next)
+	return defaultFor(2).RecoveryMiddleware(next)
 }
 
 // RecoveryMiddleware creates middleware using this handler instance
+// Each request gets a Collector (see WrapContext) so the panic error lists
+// the IDs of errors wrapped earlier in the request
+// Recovered panics record the final status code, handler latency and bytes
+// written in Details ("status", "latency_ms", "bytes_written")
+// Handlers may panic with an *HTTPError to abort with its response
 func (h *Handler) RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseRecorder{ResponseWriter: w}
+		r = h.PrepareRequest(r)
+		
 		defer func() {
 			if rec := recover(); rec != nil {
-				// Wrap panic as error
-				var err error
-				switch v := rec.(type) {
-				case error:
-					err = v
-				default:
-					err = &panicError{value: rec}
-				}
+				wrapped := h.RecoverRequest(r, rec, start, rw)
 				
-				// Wrap with error ID
-				wrapped := h.WrapWithDetails(err, "panic recovered in HTTP handler", map[string]interface{}{
-					"method": r.Method,
-					"path":   r.URL.Path,
-					"remote": r.RemoteAddr,
-				})
-				
-				// Return error response to client
-				h.writeErrorResponse(w, wrapped)
+				// Return error response to client, unless a response
+				// is already on the wire
+				if !rw.wroteHeader {
+					h.writeErrorResponse(w, wrapped)
+				}
 			}
 		}()
 		
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rw, r)
 	})
 }
 
+// ResponseProgress reports how far a response got before a panic
+// (gin.ResponseWriter implements it)
+type ResponseProgress interface {
+	Written() bool // Headers were sent
+	Status() int   // Status sent, if Written
+	Size() int     // Body bytes written, negative if none
+}
+
+// PrepareRequest sets r up the way RecoveryMiddleware does before calling
+// the handler: a Collector, echoed correlation headers, flood tracking
+// and request capture. For adapters to other routers (see erroridgin)
+func (h *Handler) PrepareRequest(r *http.Request) *http.Request {
+	ctx, _ := WithCollector(r.Context())
+	if h.flood != nil {
+		ctx = withFloodClient(ctx, r)
+	}
+	ctx = h.withCorrelation(ctx, r)
+	r = r.WithContext(ctx)
+	if h.captures != nil {
+		r = captureRequest(r)
+	}
+	return r
+}
+
+// RecoverRequest wraps a panic value recovered while serving r (prepared
+// with PrepareRequest) like RecoveryMiddleware: details record the method,
+// path, remote address, final status, latency since start and bytes
+// written. Call it from the deferred function that recovered rec, so the
+// panic stack is captured. A panicking *HTTPError was reported when
+// created and is returned as is
+func (h *Handler) RecoverRequest(r *http.Request, rec interface{}, start time.Time, progress ResponseProgress) *ErrorWithID {
+	if httpErr, ok := rec.(*HTTPError); ok {
+		return httpErr.ErrorWithID
+	}
+	
+	// Wrap panic as error, keeping where it happened
+	err := panicToError(rec)
+	panicStack := capturePanicStack()
+	
+	// Headers already sent: the client keeps that status
+	status, bytes := responseStatus(err), 0
+	if progress.Written() {
+		status = progress.Status()
+	}
+	if size := progress.Size(); size > 0 {
+		bytes = size
+	}
+	
+	return h.wrapPanic(r.Context(), err, "panic recovered in HTTP handler", map[string]interface{}{
+		"method":        r.Method,
+		"path":          r.URL.Path,
+		"remote":        h.remoteAddr(r),
+		"status":        status,
+		"latency_ms":    float64(time.Since(start).Microseconds()) / 1000,
+		"bytes_written": bytes,
+	}, panicStack)
+}
+
+// remoteAddr returns the client address, filtered by RemoteAddrFilter
+func (h *Handler) remoteAddr(r *http.Request) string {
+	if h.config.RemoteAddrFilter != nil {
+		return h.config.RemoteAddrFilter(r.RemoteAddr)
+	}
+	return r.RemoteAddr
+}
+
+// responseRecorder tracks status and body size written by a handler
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code
+func (rw *responseRecorder) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of body bytes written
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Written, Status and Size implement ResponseProgress
+func (rw *responseRecorder) Written() bool { return rw.wroteHeader }
+func (rw *responseRecorder) Status() int   { return rw.status }
+func (rw *responseRecorder) Size() int     { return rw.bytes }
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Flush implements http.Flusher for streaming handlers (e.g. SSE)
+func (rw *responseRecorder) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker for connection upgrades (e.g. websockets)
+// A hijacked connection counts as written: panics get no error response
+func (rw *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// ReadFrom implements io.ReaderFrom, keeping the underlying writer's
+// optimized copy (e.g. sendfile)
+func (rw *responseRecorder) ReadFrom(r io.Reader) (int64, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := io.Copy(rw.ResponseWriter, r)
+	rw.bytes += int(n)
+	return n, err
+}
+
 // writeErrorResponse writes JSON error response to client
+// The status comes from the error chain (StatusCoder), defaulting to 500
 func (h *Handler) writeErrorResponse(w http.ResponseWriter, err *ErrorWithID) {
+	h.writeErrorResponseStatus(w, responseStatus(err), err)
+}
+
+// writeErrorResponseStatus writes JSON error response with the given status
+// The body is encoded before anything is written; if that fails (details
+// that can't be marshaled, a panicking MarshalJSON or Error), the response
+// is retried without details and stacks, then falls back to plain text,
+// so clients always get the error ID
+func (h *Handler) writeErrorResponseStatus(w http.ResponseWriter, status int, err *ErrorWithID) {
+	response, body := h.encodeErrorResponse(err)
+	h.setErrorIDCookie(w, err)
+	if body == nil {
+		text := []byte(fmt.Sprintf("An internal error occurred. Please contact support with this error ID: %s\n", err.ID))
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		h.signResponse(w, text)
+		w.WriteHeader(status)
+		w.Write(text)
+		return
+	}
+	
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusInternalServerError)
+	if response.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(response.RetryAfter, 10))
+	}
+	setCacheHeaders(w, err, status)
+	h.signResponse(w, body)
+	w.WriteHeader(status)
 	
-	message := "An internal error occurred. Please contact support with this error ID."
+	w.Write(body)
+}
+
+// encodeErrorResponse builds and marshals the response for err, dropping
+// details and stacks if the full response can't be encoded
+// body is nil if even the reduced response fails
+func (h *Handler) encodeErrorResponse(err *ErrorWithID) (response ErrorResponse, body []byte) {
+	response, body = h.tryEncodeErrorResponse(err, false)
+	if body == nil {
+		response, body = h.tryEncodeErrorResponse(err, true)
+	}
+	return response, body
+}
+
+// tryEncodeErrorResponse is one attempt of encodeErrorResponse
+func (h *Handler) tryEncodeErrorResponse(err *ErrorWithID, reduced bool) (response ErrorResponse, body []byte) {
+	defer func() {
+		if recover() != nil {
+			body = nil
+		}
+	}()
 	
-	// In development, show more details
-	if h.config.Environment == "development" {
+	response = h.ErrorResponse(err)
+	if reduced {
+		response.Details, response.Causes = nil, nil
+		response.StackTrace, response.PanicStack = "", ""
+		response.StackFrames, response.PanicFrames = nil, nil
+		if response.Message != PublicMessage(err) {
+			// The message may come from the failing Error method
+			response.Message = genericErrorMessage
+		}
+	}
+	
+	data, marshalErr := json.Marshal(response)
+	if marshalErr != nil {
+		return response, nil
+	}
+	return response, append(data, '\n')
+}
+
+// genericErrorMessage is the response message when the error's own is hidden
+const genericErrorMessage = "An internal error occurred. Please contact support with this error ID."
+
+// ErrorResponse builds the client-facing response for err
+// Fields are filled according to the handler's ResponseDetail level, or
+// that of the error's route Policy
+func (h *Handler) ErrorResponse(err *ErrorWithID) ErrorResponse {
+	detail := h.responseDetail()
+	if err.policy != nil && err.policy.ResponseDetail != ResponseDetailDefault {
+		detail = err.policy.ResponseDetail
+	}
+	
+	message := genericErrorMessage
+	
+	// Errors may carry a message that is always safe to show
+	if public := PublicMessage(err); public != "" {
+		message = public
+	}
+	
+	// Higher detail levels show more of the error
+	var causes map[string]string
+	if detail >= ResponseDetailMessage {
 		message = err.Error()
+		causes = causeMessages(err.Causes)
 	}
 	
 	response := ErrorResponse{
-		ErrorID:   err.ID,
-		Message:   message,
-		Timestamp: err.Timestamp,
+		ErrorID:    err.ID,
+		Message:    message,
+		Code:       ErrorCode(err),
+		IncidentID: err.IncidentID,
+		Timestamp:  err.Timestamp,
+		Related:    err.Related,
+		Causes:     causes,
 	}
 	
-	json.NewEncoder(w).Encode(response)
+	if len(h.config.EchoHeaders) > 0 {
+		response.Correlation = err.Correlation
+	}
+	
+	if hint, ok := h.retryHint(err); ok {
+		response.Retryable = &hint.Retryable
+		if hint.Retryable && hint.After > 0 {
+			// Round up: retrying early defeats the hint
+			response.RetryAfter = int64((hint.After + time.Second - 1) / time.Second)
+		}
+	}
+	
+	if h.config.TimeLocation != nil {
+		response.Time = time.Unix(err.Timestamp, 0).In(h.config.TimeLocation).Format(time.RFC3339)
+	}
+	
+	response.Extensions = h.responseExtensions(err)
+	
+	if detail >= ResponseDetailDetails {
+		response.Details = h.resolveDetails(err.Details)
+	}
+	
+	if detail >= ResponseDetailFull && h.config.StackTraceTargets.has(StackToResponses) {
+		if h.config.StackFormat == StackFormatFrames {
+			response.StackFrames = err.Frames()
+			response.PanicFrames = err.PanicFrames()
+		} else {
+			response.StackTrace = err.StackTrace
+			response.PanicStack = err.PanicStack
+		}
+	}
+	
+	return response
+}
+
+// responseDetail resolves the effective response verbosity
+func (h *Handler) responseDetail() ResponseDetail {
+	if h.config.ResponseDetail != ResponseDetailDefault {
+		return h.config.ResponseDetail
+	}
+	
+	if h.config.Environment == "development" {
+		return ResponseDetailMessage
+	}
+	return ResponseDetailMinimal
 }
 
 // WriteError is a helper to manually write error responses in handlers
 func WriteError(w http.ResponseWriter, err *ErrorWithID) {
-	Default().writeErrorResponse(w, err)
+	defaultFor(2).writeErrorResponse(w, err)
 }
 
 // WriteErrorWithHandler writes error using specific handler instance
@@ -80,11 +365,24 @@ func (h *Handler) WriteError(w http.ResponseWriter, err *ErrorWithID) {
 	h.writeErrorResponse(w, err)
 }
 
+// panicToError converts a recovered panic value to an error
+// Error values are kept as is so errors.Is/As still work
+func panicToError(rec interface{}) error {
+	if err, ok := rec.(error); ok {
+		return err
+	}
+	return &panicError{value: rec}
+}
+
 // panicError wraps a panic value as an error
 type panicError struct {
-	value interface{}
+	value     interface{}
+	sanitized *string // Config.SanitizePanic output, if set
 }
 
 func (e *panicError) Error() string {
+	if e.sanitized != nil {
+		return "panic: " + *e.sanitized
+	}
 	return fmt.Sprint("panic: ", e.value)
 }
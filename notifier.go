@@ -0,0 +1,327 @@
+package errorid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by notifiers that dropped a message because
+// their per-minute limit was reached
+var ErrRateLimited = errors.New("errorid: notifier rate limit reached")
+
+// notifyTimeout bounds a notification sent from an OnError callback
+const notifyTimeout = 10 * time.Second
+
+// Notifier delivers errors to an external channel (chat, pager, tracker)
+type Notifier interface {
+	Notify(ctx context.Context, err *ErrorWithID) error
+}
+
+// NotifierFunc adapts a function to Notifier
+type NotifierFunc func(ctx context.Context, err *ErrorWithID) error
+
+// Notify calls f(ctx, err)
+func (f NotifierFunc) Notify(ctx context.Context, err *ErrorWithID) error {
+	return f(ctx, err)
+}
+
+// Route sends errors matching its filters to a Notifier
+type Route struct {
+	Notifier    Notifier
+	MinSeverity Severity      // Skip errors below this severity (zero = all)
+	Categories  []string      // Only these categories (empty = all)
+	Retries     int           // Extra attempts after a failure
+	Backoff     time.Duration // Wait before the first retry, doubled after each (default 1s)
+	Timeout     time.Duration // Per attempt (default 10s)
+	
+	// MaxConcurrent caps deliveries in flight to the notifier (0 = no cap)
+	// Deliveries over the cap wait for a slot for up to Timeout
+	// Critical errors are never capped
+	MaxConcurrent int
+	
+	// FingerprintInterval sends at most one error per fingerprint within
+	// this long; the others are skipped with ErrRateLimited (0 = all)
+	// Critical errors are always sent
+	FingerprintInterval time.Duration
+	
+	// Mask shapes the error the notifier receives (nil = unchanged)
+	Mask *FieldMask
+}
+
+// routeLimiter holds the concurrency slots and per-fingerprint send times
+// of a route
+type routeLimiter struct {
+	slots chan struct{} // nil without MaxConcurrent
+	
+	mu   sync.Mutex
+	last map[string]time.Time // by fingerprint
+}
+
+// newRouteLimiter creates the limiter of r
+func newRouteLimiter(r *Route) *routeLimiter {
+	l := &routeLimiter{last: make(map[string]time.Time)}
+	if r.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, r.MaxConcurrent)
+	}
+	return l
+}
+
+// allow reports whether fingerprint may be sent at now, at most once per
+// interval. Expired entries are swept as the map grows
+func (l *routeLimiter) allow(fingerprint string, interval time.Duration, now time.Time) bool {
+	if interval <= 0 {
+		return true
+	}
+	
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	if last, ok := l.last[fingerprint]; ok && now.Sub(last) < interval {
+		return false
+	}
+	if len(l.last) >= 1024 {
+		for fp, last := range l.last {
+			if now.Sub(last) >= interval {
+				delete(l.last, fp)
+			}
+		}
+	}
+	l.last[fingerprint] = now
+	return true
+}
+
+// acquire takes a concurrency slot, waiting until ctx ends
+func (l *routeLimiter) acquire(ctx context.Context) error {
+	if l.slots == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release returns a slot taken by acquire
+func (l *routeLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// matches reports whether err passes the route's filters
+func (r *Route) matches(err *ErrorWithID) bool {
+	if r.MinSeverity != 0 && err.Severity < r.MinSeverity {
+		return false
+	}
+	if len(r.Categories) == 0 {
+		return true
+	}
+	for _, category := range r.Categories {
+		if category == err.Category {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver notifies with retries within the route's limits
+// ErrRateLimited is not retried. Critical errors skip the limits
+func (r *Route) deliver(ctx context.Context, limiter *routeLimiter, err *ErrorWithID) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = notifyTimeout
+	}
+	
+	if !isCritical(err) {
+		if !limiter.allow(err.Fingerprint(), r.FingerprintInterval, time.Now()) {
+			return fmt.Errorf("%w: %s sent within FingerprintInterval", ErrRateLimited, err.Fingerprint())
+		}
+		
+		// Wait at most one attempt's timeout for a slot
+		acquireCtx, cancel := context.WithTimeout(ctx, timeout)
+		acquireErr := limiter.acquire(acquireCtx)
+		cancel()
+		if acquireErr != nil {
+			return acquireErr
+		}
+		defer limiter.release()
+	}
+	
+	err = r.Mask.Apply(err)
+	backoff := r.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		notifyErr := r.Notifier.Notify(attemptCtx, err)
+		cancel()
+		
+		if notifyErr == nil || errors.Is(notifyErr, ErrRateLimited) || attempt >= r.Retries {
+			return notifyErr
+		}
+		
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return notifyErr
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// Dispatcher fans errors out to several notifiers, each with its own
+// filters and retries. Routes are delivered concurrently, so a slow or
+// failing channel doesn't hold up the others:
+//
+//	dispatcher := errorid.NewDispatcher(
+//		errorid.Route{Notifier: discord, Retries: 2},
+//		errorid.Route{Notifier: pager, MinSeverity: errorid.SeverityCritical},
+//	)
+//	errorid.Configure(errorid.Config{OnError: dispatcher.OnError, AsyncCallback: true})
+type Dispatcher struct {
+	routes   []Route
+	limiters []*routeLimiter // per route
+	
+	// OnFailure is called for each route that still failed after its
+	// retries (or was rate limited). Routes run concurrently, so it may
+	// be called concurrently too
+	OnFailure func(err *ErrorWithID, n Notifier, cause error)
+}
+
+// NewDispatcher creates a Dispatcher for routes
+func NewDispatcher(routes ...Route) *Dispatcher {
+	d := &Dispatcher{routes: routes, limiters: make([]*routeLimiter, len(routes))}
+	for i := range routes {
+		d.limiters[i] = newRouteLimiter(&routes[i])
+	}
+	return d
+}
+
+// Dispatch delivers err to every matching route and waits for them
+// Returns the joined errors of routes that failed
+func (d *Dispatcher) Dispatch(ctx context.Context, err *ErrorWithID) error {
+	errs := make([]error, len(d.routes))
+	
+	var wg sync.WaitGroup
+	for i := range d.routes {
+		route := &d.routes[i]
+		if !route.matches(err) {
+			continue
+		}
+		
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if deliverErr := route.deliver(ctx, d.limiters[i], err); deliverErr != nil {
+				errs[i] = deliverErr
+				if d.OnFailure != nil {
+					d.OnFailure(err, route.Notifier, deliverErr)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	
+	return errors.Join(errs...)
+}
+
+// OnError is a Config.OnError callback dispatching err
+// Failures are reported to OnFailure
+func (d *Dispatcher) OnError(err *ErrorWithID) {
+	d.Dispatch(context.Background(), err)
+}
+
+// rateLimiter allows up to limit messages per minute (fixed window) and
+// counts the ones it suppressed. limit < 0 disables limiting
+type rateLimiter struct {
+	mu         sync.Mutex
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// allow reports whether a message may be sent at now and, if so, how many
+// were suppressed since the last one that was
+func (r *rateLimiter) allow(limit int, now time.Time) (bool, int) {
+	if limit < 0 {
+		return true, 0
+	}
+	
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	if now.Sub(r.start) >= time.Minute {
+		r.start = now
+		r.count = 0
+	}
+	
+	if r.count >= limit {
+		r.suppressed++
+		return false, 0
+	}
+	
+	r.count++
+	suppressed := r.suppressed
+	r.suppressed = 0
+	return true, suppressed
+}
+
+// postJSON sends body as JSON to url and fails on non-2xx responses
+// header (may be nil) is added to the request, e.g. for API keys
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	
+	return doPost(client, req)
+}
+
+// doPost sends a notifier request and turns non-2xx responses into errors
+func doPost(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("errorid: notifier got HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// truncate shortens s to at most n runes, marking the cut
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,119 @@
+package errorid
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// onceSweepSize is the number of tracked operations above which stale
+// ones are pruned
+const onceSweepSize = 1024
+
+// onceTracker remembers the first error of retried operations (WrapOnce)
+type onceTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]*onceEntry
+}
+
+// onceEntry is the first error of an operation and its attempt count
+type onceEntry struct {
+	first    *ErrorWithID
+	attempts int
+	last     time.Time
+}
+
+func newOnceTracker(window time.Duration) *onceTracker {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &onceTracker{window: window, entries: make(map[string]*onceEntry)}
+}
+
+// attempt returns the first error of key and the attempt count including
+// this one, or nil if key has no attempt within the window
+func (t *onceTracker) attempt(key string, now time.Time) (*ErrorWithID, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	entry := t.entries[key]
+	if entry == nil || now.Sub(entry.last) > t.window {
+		return nil, 0
+	}
+	entry.attempts++
+	entry.last = now
+	return entry.first, entry.attempts
+}
+
+// remember records first as the first error of key
+func (t *onceTracker) remember(key string, first *ErrorWithID, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	if len(t.entries) >= onceSweepSize {
+		for k, entry := range t.entries {
+			if now.Sub(entry.last) > t.window {
+				delete(t.entries, k)
+			}
+		}
+	}
+	t.entries[key] = &onceEntry{first: first, attempts: 1, last: now}
+}
+
+// onceKey identifies an operation: its context and the kind of error
+// (code, or type of the innermost error)
+func onceKey(err error, context string) string {
+	kind := ErrorCode(err)
+	if kind == "" {
+		inner := err
+		for next := errors.Unwrap(inner); next != nil; next = errors.Unwrap(inner) {
+			inner = next
+		}
+		kind = fmt.Sprintf("%T", inner)
+	}
+	return context + "\x00" + kind
+}
+
+// WrapOnce wraps err like Wrap for the first attempt of a retried
+// operation; later failures of the same operation (same context and kind
+// of error) within Config.WrapOnceWindow of the previous one reuse its ID
+// and are not logged or reported again. Details["attempts"] counts them:
+//
+//	for attempt := 0; attempt < 3; attempt++ {
+//		if err = charge(order); err == nil {
+//			break
+//		}
+//		err = errorid.WrapOnce(err, "charge order "+order.ID)
+//	}
+//
+// Include what identifies the operation in context, so concurrent
+// requests don't share an ID
+func WrapOnce(err error, context string) *ErrorWithID {
+	return defaultFor(2).WrapOnce(err, context)
+}
+
+// WrapOnce is the handler version of the package-level WrapOnce
+func (h *Handler) WrapOnce(err error, context string) *ErrorWithID {
+	if err == nil {
+		return nil
+	}
+	
+	key := onceKey(err, context)
+	now := time.Now()
+	if first, attempts := h.once.attempt(key, now); first != nil {
+		retry := *first
+		retry.Original = err
+		retry.Details = make(map[string]interface{}, len(first.Details))
+		for k, v := range first.Details {
+			retry.Details[k] = v
+		}
+		retry.Details["attempts"] = attempts
+		return &retry
+	}
+	
+	wrapped := h.wrap(nil, err, context, map[string]interface{}{"attempts": 1})
+	h.once.remember(key, wrapped, now)
+	return wrapped
+}
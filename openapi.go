@@ -0,0 +1,81 @@
+package errorid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ErrorSchemaFunc maps a wrapped error to the status code and body of the
+// OpenAPI spec's error schema. status is the default chosen by the adapter
+// Use Handler.ErrorResponse to get the ID and verbosity-aware message
+type ErrorSchemaFunc func(err *ErrorWithID, status int) (int, interface{})
+
+// StrictRequestErrorHandler returns a handler for oapi-codegen strict servers'
+// StrictHTTPServerOptions.RequestErrorHandlerFunc (request decoding failures)
+// Errors are wrapped with an ID and answered with 400 by default
+// If schema is nil, the standard ErrorResponse JSON is written
+func (h *Handler) StrictRequestErrorHandler(schema ErrorSchemaFunc) func(w http.ResponseWriter, r *http.Request, err error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		h.writeSchemaError(w, r, err, "invalid API request", http.StatusBadRequest, schema)
+	}
+}
+
+// StrictResponseErrorHandler returns a handler for oapi-codegen strict servers'
+// StrictHTTPServerOptions.ResponseErrorHandlerFunc (errors returned by the
+// strict handler). Errors are wrapped with an ID and answered with 500 by default
+// If schema is nil, the standard ErrorResponse JSON is written
+func (h *Handler) StrictResponseErrorHandler(schema ErrorSchemaFunc) func(w http.ResponseWriter, r *http.Request, err error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		h.writeSchemaError(w, r, err, "API handler failed", http.StatusInternalServerError, schema)
+	}
+}
+
+// OgenErrorHandler returns an error handler for ogen servers (WithErrorHandler)
+// The default status comes from the error's Code() method when it has one
+// (ogen's decoding errors do), otherwise 500
+// If schema is nil, the standard ErrorResponse JSON is written
+func (h *Handler) OgenErrorHandler(schema ErrorSchemaFunc) func(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+		status := http.StatusInternalServerError
+		
+		var coder interface{ Code() int }
+		if errors.As(err, &coder) && coder.Code() >= http.StatusBadRequest {
+			status = coder.Code()
+		}
+		
+		h.writeSchemaError(w, r, err, "API handler failed", status, schema)
+	}
+}
+
+// writeSchemaError wraps err (unless it already has an ID) and writes it
+// using schema, or the standard ErrorResponse when schema is nil
+func (h *Handler) writeSchemaError(w http.ResponseWriter, r *http.Request, err error, context string, status int, schema ErrorSchemaFunc) {
+	var wrapped *ErrorWithID
+	if !errors.As(err, &wrapped) {
+		wrapped = h.WrapWithDetails(err, context, map[string]interface{}{
+			"method": r.Method,
+			"path":   r.URL.Path,
+		})
+	}
+	
+	if schema == nil {
+		h.writeErrorResponseStatus(w, status, wrapped)
+		return
+	}
+	
+	status, body := schema(wrapped, status)
+	data, marshalErr := json.Marshal(body)
+	if marshalErr != nil {
+		// Fall back to the standard response so the ID still reaches the client
+		h.writeErrorResponseStatus(w, status, wrapped)
+		return
+	}
+	
+	data = append(data, '\n')
+	w.Header().Set("Content-Type", "application/json")
+	h.signResponse(w, data)
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,83 @@
+//go:build windows
+
+package erroridoslog
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Event types for ReportEventW
+const (
+	eventlogErrorType       = 0x0001
+	eventlogInformationType = 0x0004
+	
+	// eventlogMaxMessage is the insertion string limit of ReportEventW
+	eventlogMaxMessage = 31839
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	procReportEvent           = advapi32.NewProc("ReportEventW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+)
+
+// eventLog writes to the Windows Event Log
+type eventLog struct {
+	handle uintptr
+}
+
+func openSink(source string) (sink, error) {
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	
+	handle, _, callErr := procRegisterEventSource.Call(0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return nil, callErr
+	}
+	return &eventLog{handle: handle}, nil
+}
+
+func (l *eventLog) error(msg string) error {
+	return l.report(eventlogErrorType, 1, msg)
+}
+
+func (l *eventLog) info(msg string) error {
+	return l.report(eventlogInformationType, 2, msg)
+}
+
+// report writes msg as the single insertion string of an event
+func (l *eventLog) report(eventType uint16, eventID uint32, msg string) error {
+	text, err := syscall.UTF16PtrFromString(sanitizeMessage(msg, eventlogMaxMessage))
+	if err != nil {
+		return err
+	}
+	
+	strs := []*uint16{text}
+	ok, _, callErr := procReportEvent.Call(
+		l.handle,
+		uintptr(eventType),
+		0, // category
+		uintptr(eventID),
+		0, // user SID
+		uintptr(len(strs)),
+		0, // raw data size
+		uintptr(unsafe.Pointer(&strs[0])),
+		0, // raw data
+	)
+	if ok == 0 {
+		return callErr
+	}
+	return nil
+}
+
+func (l *eventLog) close() error {
+	ok, _, callErr := procDeregisterEventSource.Call(l.handle)
+	if ok == 0 {
+		return callErr
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package erroridoslog provides errorid loggers that write to the native
+// OS log: the Windows Event Log on Windows and the unified log (os_log) on
+// macOS. It only uses the standard library (and cgo on macOS)
+//
+//	logger, err := erroridoslog.New("com.example.agent")
+//	if err != nil {
+//		// unsupported platform, or the source could not be registered
+//	}
+//	defer logger.Close()
+//
+//	errorid.Configure(errorid.Config{Logger: logger})
+package erroridoslog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf16"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// ErrUnsupported is returned by New on platforms without a native logger
+// (and on macOS builds without cgo)
+var ErrUnsupported = errors.New("erroridoslog: native OS log not supported on this platform")
+
+// Logger is an errorid.Logger writing to the native OS log
+type Logger struct {
+	sink     sink
+	failures atomic.Uint64
+}
+
+var _ errorid.Logger = (*Logger)(nil)
+
+// sink is the platform-specific log writer
+type sink interface {
+	error(msg string) error
+	info(msg string) error
+	close() error
+}
+
+// New opens the native OS log under source
+// On Windows source is the event source name (register it with an event
+// message file for clean Event Viewer output); on macOS it is the os_log
+// subsystem, with category "errorid"
+func New(source string) (*Logger, error) {
+	s, err := openSink(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{sink: s}, nil
+}
+
+// Error implements errorid.Logger, writing an error-level entry
+func (l *Logger) Error(errorID string, err error, context string, details map[string]interface{}, stackTrace string) {
+	if l.sink.error(formatError(errorID, err, context, details, stackTrace)) != nil {
+		l.failures.Add(1)
+	}
+}
+
+// Info implements errorid.Logger, writing an informational entry
+func (l *Logger) Info(msg string) {
+	if l.sink.info(msg) != nil {
+		l.failures.Add(1)
+	}
+}
+
+// Failures returns the number of entries the OS log rejected
+func (l *Logger) Failures() uint64 {
+	return l.failures.Load()
+}
+
+// Close releases the OS log handle
+func (l *Logger) Close() error {
+	return l.sink.close()
+}
+
+// formatError renders an entry in the DefaultLogger layout
+func formatError(errorID string, err error, context string, details map[string]interface{}, stackTrace string) string {
+	msg := fmt.Sprintf("ID=%s | Context=%s | Error=%v | Details=%+v", errorID, context, err, details)
+	if stackTrace != "" {
+		msg += " | StackTrace=" + stackTrace
+	}
+	return msg
+}
+
+// truncatedSuffix marks entries cut by sanitizeMessage
+const truncatedSuffix = " ...(truncated)"
+
+// sanitizeMessage replaces NUL characters, which would end the entry early,
+// and truncates msg to at most max UTF-16 code units
+func sanitizeMessage(msg string, max int) string {
+	msg = strings.ReplaceAll(msg, "\x00", `\0`)
+	if len(utf16.Encode([]rune(msg))) <= max {
+		return msg
+	}
+	
+	limit := max - len(truncatedSuffix)
+	units := 0
+	for i, r := range msg {
+		units += utf16.RuneLen(r)
+		if units > limit {
+			return msg[:i] + truncatedSuffix
+		}
+	}
+	return msg
+}
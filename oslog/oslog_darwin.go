@@ -0,0 +1,53 @@
+//go:build darwin && cgo
+
+package erroridoslog
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+static os_log_t errorid_log_create(const char *subsystem) {
+	return os_log_create(subsystem, "errorid");
+}
+
+static void errorid_log(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import "unsafe"
+
+// unifiedLog writes to the macOS unified logging system
+type unifiedLog struct {
+	log C.os_log_t
+}
+
+func openSink(source string) (sink, error) {
+	subsystem := C.CString(source)
+	defer C.free(unsafe.Pointer(subsystem))
+	
+	return &unifiedLog{log: C.errorid_log_create(subsystem)}, nil
+}
+
+func (l *unifiedLog) error(msg string) error {
+	l.write(C.OS_LOG_TYPE_ERROR, msg)
+	return nil
+}
+
+func (l *unifiedLog) info(msg string) error {
+	l.write(C.OS_LOG_TYPE_INFO, msg)
+	return nil
+}
+
+func (l *unifiedLog) write(logType C.os_log_type_t, msg string) {
+	text := C.CString(msg)
+	defer C.free(unsafe.Pointer(text))
+	
+	C.errorid_log(l.log, logType, text)
+}
+
+// close is a no-op: os_log handles live for the whole process
+func (l *unifiedLog) close() error {
+	return nil
+}
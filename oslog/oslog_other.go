@@ -0,0 +1,7 @@
+//go:build !windows && !(darwin && cgo)
+
+package erroridoslog
+
+func openSink(source string) (sink, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,68 @@
+package erroridoslog
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// Test entries use the DefaultLogger layout
+func TestFormatError(t *testing.T) {
+	msg := formatError("ERR-20251023-A3F9B2", errors.New("boom"), "sync", map[string]interface{}{"job": 7}, "")
+	if msg != "ID=ERR-20251023-A3F9B2 | Context=sync | Error=boom | Details=map[job:7]" {
+		t.Errorf("unexpected entry: %s", msg)
+	}
+	
+	withStack := formatError("ERR-20251023-A3F9B2", errors.New("boom"), "sync", nil, "main.run\n")
+	if !strings.HasSuffix(withStack, " | StackTrace=main.run\n") {
+		t.Errorf("expected stack trace suffix, got %s", withStack)
+	}
+}
+
+// Test New reports unsupported platforms
+func TestNewUnsupported(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("native logger available")
+	}
+	
+	if _, err := New("errorid-test"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+// failingSink rejects every entry
+type failingSink struct{}
+
+func (failingSink) error(msg string) error { return errors.New("rejected") }
+func (failingSink) info(msg string) error  { return errors.New("rejected") }
+func (failingSink) close() error           { return nil }
+
+// Test rejected entries are counted
+func TestFailures(t *testing.T) {
+	logger := &Logger{sink: failingSink{}}
+	logger.Error("ERR-20251023-A3F9B2", errors.New("boom"), "sync", nil, "")
+	logger.Info("hello")
+	
+	if logger.Failures() != 2 {
+		t.Errorf("expected 2 failures, got %d", logger.Failures())
+	}
+}
+
+// Test messages are stripped of NUL and truncated to the limit
+func TestSanitizeMessage(t *testing.T) {
+	if msg := sanitizeMessage("a\x00b", 100); msg != `a\0b` {
+		t.Errorf("expected NUL to be escaped, got %q", msg)
+	}
+	
+	long := strings.Repeat("é", 100)
+	msg := sanitizeMessage(long, 50)
+	if len(utf16.Encode([]rune(msg))) > 50 || !strings.HasSuffix(msg, truncatedSuffix) {
+		t.Errorf("expected truncated message, got %q", msg)
+	}
+	
+	if msg := sanitizeMessage("short", 50); msg != "short" {
+		t.Errorf("expected message unchanged, got %q", msg)
+	}
+}
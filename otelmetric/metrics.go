@@ -0,0 +1,160 @@
+// Package erroridotel records errorid metrics through OpenTelemetry, so
+// services exporting OTLP metrics get error counts without a Prometheus
+// endpoint
+package erroridotel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// ScopeName is the instrumentation scope of the meter
+const ScopeName = "github.com/isaui/go-support-id-error/otelmetric"
+
+// Metrics holds the instruments recording reported errors:
+//
+//	errorid.errors           counter of reported errors, by severity, category and code
+//	errorid.report.duration  histogram of the time spent reporting (logging, storing, OnError) in seconds
+type Metrics struct {
+	meter    metric.Meter
+	errors   metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// New creates the instruments on mp, or on the global MeterProvider
+// (otel.GetMeterProvider) if mp is nil
+func New(mp metric.MeterProvider) (*Metrics, error) {
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(ScopeName)
+	
+	errors, err := meter.Int64Counter("errorid.errors",
+		metric.WithDescription("Errors reported by errorid handlers"),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram("errorid.report.duration",
+		metric.WithDescription("Time spent reporting an error (logging, storing, OnError)"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	
+	return &Metrics{meter: meter, errors: errors, duration: duration}, nil
+}
+
+// Interceptor records the errors passing through it. Put it last in
+// Config.Interceptors to count only errors that sampling lets through:
+//
+//	metrics, _ := erroridotel.New(nil)
+//	errorid.Configure(errorid.Config{Interceptors: []errorid.Interceptor{errorid.Sample(0.1), metrics.Interceptor()}})
+func (m *Metrics) Interceptor() errorid.Interceptor {
+	return func(next errorid.WrapFunc) errorid.WrapFunc {
+		return func(err *errorid.ErrorWithID) *errorid.ErrorWithID {
+			start := time.Now()
+			result := next(err)
+			
+			attrs := metric.WithAttributes(attributes(err)...)
+			ctx := context.Background()
+			m.errors.Add(ctx, 1, attrs)
+			m.duration.Record(ctx, time.Since(start).Seconds(), attrs)
+			return result
+		}
+	}
+}
+
+// attributes returns the metric attributes of err
+func attributes(err *errorid.ErrorWithID) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("severity", err.Severity.String())}
+	if err.Category != "" {
+		attrs = append(attrs, attribute.String("category", err.Category))
+	}
+	if code := errorid.ErrorCode(err); code != "" {
+		attrs = append(attrs, attribute.String("code", code))
+	}
+	if err.Component != "" {
+		attrs = append(attrs, attribute.String("component", err.Component))
+	}
+	return attrs
+}
+
+// Observe exports the counters of h.Stats as observable counters
+// (errorid.wrapped, errorid.callback.panics, ...), read at each collection,
+// with a "component" attribute if h has a Config.Component, so several
+// handlers can be observed side by side. Once h.OK is used, the outcomes
+// by context (Stats.Contexts) are exported too, as errorid.context.ok and
+// errorid.context.errors with a "context" attribute, to compute error
+// ratios for SLO alerts
+// Unregister the returned registration when h is discarded
+func (m *Metrics) Observe(h *errorid.Handler) (metric.Registration, error) {
+	names := []string{
+		"errorid.wrapped",
+		"errorid.callback.panics",
+		"errorid.callback.timeouts",
+		"errorid.callback.dropped",
+		"errorid.callback.shed",
+		"errorid.id.fallbacks",
+		"errorid.flood.suppressed",
+		"errorid.store.failures",
+		"errorid.idlog.failures",
+		"errorid.collapsed",
+		"errorid.forwarded",
+	}
+	counters := make([]metric.Int64ObservableCounter, len(names))
+	instruments := make([]metric.Observable, len(names))
+	for i, name := range names {
+		counter, err := m.meter.Int64ObservableCounter(name)
+		if err != nil {
+			return nil, err
+		}
+		counters[i], instruments[i] = counter, counter
+	}
+	contextOK, err := m.meter.Int64ObservableCounter("errorid.context.ok")
+	if err != nil {
+		return nil, err
+	}
+	contextErrors, err := m.meter.Int64ObservableCounter("errorid.context.errors")
+	if err != nil {
+		return nil, err
+	}
+	instruments = append(instruments, contextOK, contextErrors)
+	
+	var base []attribute.KeyValue
+	if component := h.Config().Component; component != "" {
+		base = append(base, attribute.String("component", component))
+	}
+	opts := []metric.ObserveOption{metric.WithAttributes(base...)}
+	
+	return m.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		s := h.Stats()
+		for i, value := range []uint64{
+			s.Wrapped,
+			s.CallbackPanics,
+			s.CallbackTimeouts,
+			s.CallbackDropped,
+			s.CallbackShed,
+			s.IDFallbacks,
+			s.FloodSuppressed,
+			s.StoreFailures,
+			s.IDLogFailures,
+			s.Collapsed,
+			s.Forwarded,
+		} {
+			o.ObserveInt64(counters[i], int64(value), opts...)
+		}
+		for name, rate := range s.Contexts {
+			attrs := metric.WithAttributes(append(base[:len(base):len(base)], attribute.String("context", name))...)
+			o.ObserveInt64(contextOK, int64(rate.OK), attrs)
+			o.ObserveInt64(contextErrors, int64(rate.Errors), attrs)
+		}
+		return nil
+	}, instruments...)
+}
@@ -0,0 +1,155 @@
+package erroridotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	errorid "github.com/isaui/go-support-id-error"
+)
+
+// collect returns the collected metrics by name
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Metrics {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]metricdata.Metrics)
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			byName[m.Name] = m
+		}
+	}
+	return byName
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	metrics, err := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	h := errorid.New(errorid.Config{
+		Logger:       errorid.NewDefaultLogger(nopWriter{}),
+		Interceptors: []errorid.Interceptor{metrics.Interceptor()},
+	})
+	registration, err := metrics.Observe(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registration.Unregister()
+	
+	errQuota := errorid.Define("QUOTA", http.StatusTooManyRequests, "quota exceeded")
+	h.Wrap(errors.New("boom"), "a")
+	h.Wrap(errors.New("boom"), "b")
+	errQuota.NewWith(h, nil)
+	
+	got := collect(t, reader)
+	
+	sum, ok := got["errorid.errors"].Data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("Expected an errorid.errors counter, got %v", got["errorid.errors"])
+	}
+	counts := make(map[string]int64)
+	for _, point := range sum.DataPoints {
+		code, _ := point.Attributes.Value("code")
+		counts[code.AsString()] += point.Value
+	}
+	if counts[""] != 2 || counts["QUOTA"] != 1 {
+		t.Errorf("Expected 2 uncoded errors and 1 QUOTA, got %v", counts)
+	}
+	
+	if hist, ok := got["errorid.report.duration"].Data.(metricdata.Histogram[float64]); !ok || len(hist.DataPoints) == 0 {
+		t.Error("Expected report durations")
+	}
+	
+	wrapped, ok := got["errorid.wrapped"].Data.(metricdata.Sum[int64])
+	if !ok || len(wrapped.DataPoints) != 1 || wrapped.DataPoints[0].Value != 3 {
+		t.Errorf("Expected errorid.wrapped = 3, got %v", got["errorid.wrapped"].Data)
+	}
+}
+
+// nopWriter discards log output
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+// Test handlers of several components are told apart
+func TestMetricsComponents(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	metrics, err := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	for component, n := range map[string]int{"billing": 2, "search": 1} {
+		h := errorid.New(errorid.Config{
+			Logger:       errorid.NewDefaultLogger(nopWriter{}),
+			Component:    component,
+			Interceptors: []errorid.Interceptor{metrics.Interceptor()},
+		})
+		registration, err := metrics.Observe(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer registration.Unregister()
+		for i := 0; i < n; i++ {
+			h.Wrap(errors.New("boom"), "op")
+		}
+	}
+	
+	got := collect(t, reader)
+	for _, name := range []string{"errorid.errors", "errorid.wrapped"} {
+		sum, ok := got[name].Data.(metricdata.Sum[int64])
+		if !ok {
+			t.Fatalf("Expected a %s counter, got %v", name, got[name])
+		}
+		counts := make(map[string]int64)
+		for _, point := range sum.DataPoints {
+			component, _ := point.Attributes.Value("component")
+			counts[component.AsString()] += point.Value
+		}
+		if counts["billing"] != 2 || counts["search"] != 1 {
+			t.Errorf("Expected %s by component billing=2 search=1, got %v", name, counts)
+		}
+	}
+}
+
+// Test error ratios by context from OK
+func TestMetricsContextRates(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	metrics, err := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	h := errorid.New(errorid.Config{Logger: errorid.NewDefaultLogger(nopWriter{})})
+	registration, err := metrics.Observe(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registration.Unregister()
+	
+	for i := 0; i < 3; i++ {
+		h.OK("checkout")
+	}
+	h.Wrap(errors.New("boom"), "checkout")
+	
+	got := collect(t, reader)
+	for name, want := range map[string]int64{"errorid.context.ok": 3, "errorid.context.errors": 1} {
+		sum, ok := got[name].Data.(metricdata.Sum[int64])
+		if !ok || len(sum.DataPoints) != 1 {
+			t.Fatalf("Expected one %s point, got %v", name, got[name])
+		}
+		point := sum.DataPoints[0]
+		context, _ := point.Attributes.Value("context")
+		if context.AsString() != "checkout" || point.Value != want {
+			t.Errorf("Expected %s{context=checkout} = %d, got %s = %d", name, want, context.AsString(), point.Value)
+		}
+	}
+}
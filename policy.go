@@ -0,0 +1,85 @@
+package errorid
+
+import (
+	"context"
+	mrand "math/rand/v2"
+	"net/http"
+)
+
+// policyKey is the context key for the route's Policy
+type policyKey struct{}
+
+// Policy overrides the handler's error handling for some routes, like
+// webhook receivers that need different verbosity, sampling or alerting
+// than user-facing pages. Zero fields keep the handler's behavior
+type Policy struct {
+	// ResponseDetail is the verbosity of error responses on the route
+	ResponseDetail ResponseDetail
+	
+	// SampleRate reports (logs and passes to OnError) only this fraction
+	// of errors, if between 0 and 1. Critical errors are always reported
+	SampleRate float64
+	
+	// Category is given to errors with no category of their own, so
+	// Dispatcher routes (Route.Categories) can send them elsewhere
+	Category string
+}
+
+// Middleware applies p to errors wrapped with the request context:
+//
+//	r.With(errorid.Policy{SampleRate: 0.1, Category: "webhooks"}.Middleware).Post("/webhooks/stripe", h)
+//
+// It also applies to panics recovered by an outer RecoveryMiddleware
+func (p Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithPolicy(r.Context(), p)))
+	})
+}
+
+// WithPolicy returns a context whose wrapped errors follow p. The request
+// Collector, if any, records it too, so errors wrapped with the outer
+// request context (as RecoveryMiddleware does) follow it
+func WithPolicy(ctx context.Context, p Policy) context.Context {
+	if c := CollectorFromContext(ctx); c != nil {
+		c.mu.Lock()
+		c.policy = &p
+		c.mu.Unlock()
+	}
+	return context.WithValue(ctx, policyKey{}, &p)
+}
+
+// PolicyFromContext returns the Policy of ctx, or nil
+func PolicyFromContext(ctx context.Context) *Policy {
+	if ctx == nil {
+		return nil
+	}
+	if p, ok := ctx.Value(policyKey{}).(*Policy); ok {
+		return p
+	}
+	if c := CollectorFromContext(ctx); c != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.policy
+	}
+	return nil
+}
+
+// sampled reports whether err is reported under p (nil p reports all)
+func (p *Policy) sampled(err *ErrorWithID) bool {
+	if p == nil || p.SampleRate <= 0 || p.SampleRate >= 1 || isCritical(err) {
+		return true
+	}
+	return mrand.Float64() < p.SampleRate
+}
+
+// reportPolicy runs the reporting pipeline for err unless its policy
+// or flags sample it out
+func (h *Handler) reportPolicy(err *ErrorWithID) *ErrorWithID {
+	h.logID(err)
+	if !err.policy.sampled(err) || !err.flags.sampled(err) {
+		return err
+	}
+	reported := h.report(err)
+	h.notifyFlagged(err)
+	return reported
+}
@@ -0,0 +1,172 @@
+package errorid
+
+import (
+	"context"
+	"errors"
+)
+
+// Protect returns a panic-safe version of fn using the default handler
+// Panics and returned errors come back wrapped with an ID under context
+// Useful for plugin hooks and user-supplied callbacks that must not crash
+// the host
+func Protect(context string, fn func() error) func() error {
+	return defaultFor(2).Protect(context, fn)
+}
+
+// Protect returns a panic-safe version of fn using this handler instance
+func (h *Handler) Protect(context string, fn func() error) func() error {
+	return func() error {
+		if wrapped := h.runProtected(nil, context, fn); wrapped != nil {
+			return wrapped
+		}
+		return nil
+	}
+}
+
+// Protect1 is Protect for functions returning a value and an error
+// On panic the zero value of T is returned
+func Protect1[T any](context string, fn func() (T, error)) func() (T, error) {
+	return Protect1With(defaultFor(2), context, fn)
+}
+
+// Protect1With is Protect1 using the given handler instance
+func Protect1With[T any](h *Handler, context string, fn func() (T, error)) func() (T, error) {
+	return func() (T, error) {
+		var result T
+		wrapped := h.runProtected(nil, context, func() error {
+			var err error
+			result, err = fn()
+			return err
+		})
+		if wrapped != nil {
+			return result, wrapped
+		}
+		return result, nil
+	}
+}
+
+// ProtectFunc is Protect for functions taking one argument and returning a
+// value and an error, e.g. plugin entry points
+func ProtectFunc[A, T any](context string, fn func(A) (T, error)) func(A) (T, error) {
+	return ProtectFuncWith(defaultFor(2), context, fn)
+}
+
+// ProtectFuncWith is ProtectFunc using the given handler instance
+func ProtectFuncWith[A, T any](h *Handler, context string, fn func(A) (T, error)) func(A) (T, error) {
+	return func(arg A) (T, error) {
+		return Protect1With(h, context, func() (T, error) {
+			return fn(arg)
+		})()
+	}
+}
+
+// Try runs fn using the default handler and returns its failure, if any, as
+// a wrapped error: returned errors, errors raised with Must/MustNil, and
+// panics (with the detail "panic" = true) alike
+// Eases adoption in code that uses panics for control flow:
+//
+//	err := errorid.Try(func() error {
+//		cfg := errorid.Must(loadConfig())
+//		errorid.MustNil(cfg.Validate())
+//		return apply(cfg)
+//	})
+func Try(fn func() error) *ErrorWithID {
+	return defaultFor(2).Try(fn)
+}
+
+// Try is the handler instance version of Try
+func (h *Handler) Try(fn func() error) *ErrorWithID {
+	return h.runProtected(nil, "", fn)
+}
+
+// TryContext is Try for fn serving a request: errors are wrapped with ctx
+// (its Collector, details, ...) under context. For adapters to non-HTTP
+// servers (see erroridgrpc)
+func (h *Handler) TryContext(ctx context.Context, context string, fn func() error) *ErrorWithID {
+	return h.runProtected(ctx, context, fn)
+}
+
+// Must returns val, or panics with err if it is non-nil
+// Inside Try (or a Protect decorator) the panic becomes err, wrapped with an ID
+func Must[T any](val T, err error) T {
+	if err != nil {
+		panic(&mustError{err: err})
+	}
+	return val
+}
+
+// MustNil panics with err if it is non-nil, like Must for error-only calls
+func MustNil(err error) {
+	if err != nil {
+		panic(&mustError{err: err})
+	}
+}
+
+// Check returns val and, if err is non-nil, err wrapped with the default
+// handler, replacing the if-wrap-return block at (T, error) call sites:
+//
+//	user, err := repo.FindUser(ctx, id)
+//	return errorid.Check(user, err, "find user")
+//
+// A nil err gives a nil error (not a nil *ErrorWithID)
+func Check[T any](val T, err error, context string) (T, error) {
+	return CheckWith(defaultFor(2), val, err, context)
+}
+
+// CheckWith is Check using the given handler instance
+func CheckWith[T any](h *Handler, val T, err error, context string) (T, error) {
+	if err == nil {
+		return val, nil
+	}
+	return val, h.wrap(nil, err, context, nil)
+}
+
+// mustError carries an error raised by Must through panic/recover
+// Outside Try it still prints as the original error
+type mustError struct {
+	err error
+}
+
+func (e *mustError) Error() string {
+	return e.err.Error()
+}
+
+func (e *mustError) Unwrap() error {
+	return e.err
+}
+
+// runProtected calls fn, converting a panic or returned error to a wrapped
+// error. Errors that already carry an ID are returned as is
+// Panics get the detail "panic" = true
+func (h *Handler) runProtected(ctx context.Context, context string, fn func() error) (wrapped *ErrorWithID) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			// Errors raised with Must are ordinary errors, not crashes
+			if must, ok := rec.(*mustError); ok {
+				wrapped = h.wrapExisting(ctx, must.err, context)
+				return
+			}
+			
+			wrapped = h.wrapPanic(ctx, panicToError(rec), context, map[string]interface{}{
+				"panic": true,
+			}, capturePanicStack())
+		}
+	}()
+	
+	return h.wrapExisting(ctx, fn(), context)
+}
+
+// wrapExisting wraps err unless it already carries an ID
+func (h *Handler) wrapExisting(ctx context.Context, err error, context string) *ErrorWithID {
+	if err == nil {
+		return nil
+	}
+	
+	// Already wrapped further down: keep that ID
+	var existing *ErrorWithID
+	if errors.As(err, &existing) {
+		return existing
+	}
+	
+	return h.wrap(ctx, err, context, nil)
+}
@@ -0,0 +1,155 @@
+package errorid
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+)
+
+// callbackQueue holds async OnError calls waiting for a MaxPendingCallbacks
+// slot, highest severity first (FIFO within a severity). When full, the
+// lowest-severity call is shed. Methods are safe on a nil queue
+type callbackQueue struct {
+	mu    sync.Mutex
+	items callbackHeap
+	size  int
+	seq   uint64
+}
+
+// queuedCallback is a waiting call and its arrival order
+type queuedCallback struct {
+	err *ErrorWithID
+	seq uint64
+}
+
+func newCallbackQueue(size int) *callbackQueue {
+	return &callbackQueue{size: size}
+}
+
+// push queues err, returning the call shed to make room (err itself if
+// nothing queued has a lower severity), or nil
+func (q *callbackQueue) push(err *ErrorWithID) (shed *ErrorWithID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	
+	q.seq++
+	item := queuedCallback{err: err, seq: q.seq}
+	if len(q.items) < q.size {
+		heap.Push(&q.items, item)
+		return nil
+	}
+	
+	// Newest of the lowest severity goes first
+	lowest := -1
+	for i, queued := range q.items {
+		if lowest < 0 || queued.err.Severity < q.items[lowest].err.Severity ||
+			(queued.err.Severity == q.items[lowest].err.Severity && queued.seq > q.items[lowest].seq) {
+			lowest = i
+		}
+	}
+	if lowest < 0 || q.items[lowest].err.Severity >= err.Severity {
+		return err
+	}
+	
+	shed = q.items[lowest].err
+	heap.Remove(&q.items, lowest)
+	heap.Push(&q.items, item)
+	return shed
+}
+
+// pop removes and returns the most urgent waiting call, or nil
+func (q *callbackQueue) pop() *ErrorWithID {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	
+	if len(q.items) == 0 {
+		return nil
+	}
+	return heap.Pop(&q.items).(queuedCallback).err
+}
+
+// len returns the number of waiting calls
+func (q *callbackQueue) len() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// callbackHeap implements heap.Interface over queued calls
+type callbackHeap []queuedCallback
+
+func (h callbackHeap) Len() int { return len(h) }
+
+func (h callbackHeap) Less(i, j int) bool {
+	if h[i].err.Severity != h[j].err.Severity {
+		return h[i].err.Severity > h[j].err.Severity
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h callbackHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *callbackHeap) Push(x interface{}) { *h = append(*h, x.(queuedCallback)) }
+
+func (h *callbackHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// enqueueCallback queues an async call that found no free slot, shedding
+// the least urgent one if the queue is full
+func (h *Handler) enqueueCallback(err *ErrorWithID) {
+	h.inflight.Add(1)
+	if shed := h.queue.push(err); shed != nil {
+		h.inflight.Done()
+		h.stats.callbackShed.Add(1)
+		h.callbackFailed(shed, fmt.Errorf("%w: shed for more severe errors (CallbackQueueSize)", ErrCallbackDropped))
+	}
+	
+	// A slot may have freed since it was checked
+	select {
+	case h.pending <- struct{}{}:
+		go h.drainCallbacks()
+	default:
+	}
+}
+
+// runAsync runs an async OnError call; with a slot, it then runs queued
+// calls until the queue is empty
+func (h *Handler) runAsync(err *ErrorWithID, acquired bool) {
+	h.runCallback(err)
+	h.inflight.Done()
+	if acquired {
+		h.drainCallbacks()
+	}
+}
+
+// drainCallbacks runs queued calls while holding a pending slot, and
+// releases it once the queue is empty
+func (h *Handler) drainCallbacks() {
+	for {
+		for err := h.queue.pop(); err != nil; err = h.queue.pop() {
+			h.runCallback(err)
+			h.inflight.Done()
+		}
+		<-h.pending
+		
+		// Calls queued after the last pop but before the release
+		if h.queue.len() == 0 {
+			return
+		}
+		select {
+		case h.pending <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
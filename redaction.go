@@ -0,0 +1,250 @@
+package errorid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Redaction strategies of a RedactionRule
+const (
+	RedactReplace = "replace" // Replace with Replacement (default "[REDACTED]")
+	RedactRemove  = "remove"  // Delete the detail (keys) or the match (patterns)
+	RedactHash    = "hash"    // "sha256:" and 12 hex digits, to correlate without revealing
+	RedactMask    = "mask"    // Keep the last 4 characters, "*" for the rest
+)
+
+// ErrInvalidRedaction is returned for redaction rules without matchers,
+// with a bad glob or regexp, or an unknown strategy
+var ErrInvalidRedaction = errors.New("errorid: invalid redaction rule")
+
+// RedactionRule scrubs error details matching Keys or Pattern
+type RedactionRule struct {
+	Name        string   `json:"name"`                  // Shown in audits (RedactionPolicy.Hits)
+	Keys        []string `json:"keys,omitempty"`        // Detail keys, path.Match globs, case-insensitive, at any depth
+	Pattern     string   `json:"pattern,omitempty"`     // Regexp matched in string values
+	Strategy    string   `json:"strategy,omitempty"`    // RedactReplace (default), RedactRemove, RedactHash, RedactMask
+	Replacement string   `json:"replacement,omitempty"` // For RedactReplace
+	
+	pattern *regexp.Regexp
+	hits    atomic.Uint64
+}
+
+// RedactionPolicy is a set of redaction rules kept outside application
+// code, so security teams can manage and audit PII policies in a file:
+//
+//	{
+//	  "version": "2025-10",
+//	  "rules": [
+//	    {"name": "credentials", "keys": ["password", "*token*"], "strategy": "remove"},
+//	    {"name": "emails", "pattern": "[\\w.+-]+@[\\w-]+\\.[\\w.]+", "strategy": "hash"},
+//	    {"name": "cards", "keys": ["card_number"], "strategy": "mask"}
+//	  ]
+//	}
+//
+// Use its Interceptor in Config.Interceptors; details are scrubbed before
+// they are logged, stored, passed to OnError or sent to clients
+type RedactionPolicy struct {
+	Version string           `json:"version,omitempty"`
+	Rules   []*RedactionRule `json:"rules"`
+}
+
+// LoadRedactionPolicy reads a RedactionPolicy from a JSON file. YAML
+// policies can be converted first (e.g. sigs.k8s.io/yaml.YAMLToJSON)
+func LoadRedactionPolicy(file string) (*RedactionPolicy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParseRedactionPolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("errorid: reading redaction policy %s: %w", file, err)
+	}
+	return policy, nil
+}
+
+// ParseRedactionPolicy decodes and validates a JSON RedactionPolicy
+func ParseRedactionPolicy(data []byte) (*RedactionPolicy, error) {
+	var policy RedactionPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+	if err := policy.compile(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// compile validates the rules and compiles their patterns
+func (p *RedactionPolicy) compile() error {
+	for i, rule := range p.Rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+	
+		if len(rule.Keys) == 0 && rule.Pattern == "" {
+			return fmt.Errorf("%w %s: no keys or pattern", ErrInvalidRedaction, name)
+		}
+		for j, key := range rule.Keys {
+			rule.Keys[j] = strings.ToLower(key)
+			if _, err := path.Match(rule.Keys[j], ""); err != nil {
+				return fmt.Errorf("%w %s: key %q: %v", ErrInvalidRedaction, name, key, err)
+			}
+		}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("%w %s: %v", ErrInvalidRedaction, name, err)
+			}
+			rule.pattern = pattern
+		}
+	
+		switch rule.Strategy {
+		case "":
+			rule.Strategy = RedactReplace
+		case RedactReplace, RedactRemove, RedactHash, RedactMask:
+		default:
+			return fmt.Errorf("%w %s: unknown strategy %q", ErrInvalidRedaction, name, rule.Strategy)
+		}
+		if rule.Strategy == RedactReplace && rule.Replacement == "" {
+			rule.Replacement = "[REDACTED]"
+		}
+	}
+	return nil
+}
+
+// Interceptor scrubs the details of every reported error. Key rules also
+// cover Lazy values, which are then never evaluated; patterns only see
+// values already resolved
+func (p *RedactionPolicy) Interceptor() Interceptor {
+	return func(next WrapFunc) WrapFunc {
+		return func(err *ErrorWithID) *ErrorWithID {
+			err.Details = p.Redact(err.Details)
+			return next(err)
+		}
+	}
+}
+
+// Redact returns a scrubbed copy of details; details itself is unchanged
+func (p *RedactionPolicy) Redact(details map[string]interface{}) map[string]interface{} {
+	if len(details) == 0 {
+		return details
+	}
+	redacted, _ := p.redactValue(details, 0).(map[string]interface{})
+	return redacted
+}
+
+// Hits returns how many values each rule redacted, by rule name, for
+// audits
+func (p *RedactionPolicy) Hits() map[string]uint64 {
+	hits := make(map[string]uint64, len(p.Rules))
+	for _, rule := range p.Rules {
+		hits[rule.Name] += rule.hits.Load()
+	}
+	return hits
+}
+
+// redactValue scrubs nested maps, slices and strings
+func (p *RedactionPolicy) redactValue(value interface{}, depth int) interface{} {
+	if depth > maxSanitizeDepth {
+		return value
+	}
+	
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			rule := p.keyRule(key)
+			switch {
+			case rule == nil:
+				out[key] = p.redactValue(val, depth+1)
+			case rule.Strategy != RedactRemove:
+				out[key] = rule.apply(fmt.Sprint(resolveForRedaction(val)))
+			}
+			if rule != nil {
+				rule.hits.Add(1)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = p.redactValue(val, depth+1)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, val := range v {
+			out[i] = p.redactString(val)
+		}
+		return out
+	case string:
+		return p.redactString(v)
+	}
+	return value
+}
+
+// resolveForRedaction keeps Lazy values unevaluated: hashing or masking
+// one works on a placeholder
+func resolveForRedaction(value interface{}) interface{} {
+	switch value.(type) {
+	case Lazy, func() interface{}:
+		return "<lazy>"
+	}
+	return value
+}
+
+// keyRule is the first key rule matching key
+func (p *RedactionPolicy) keyRule(key string) *RedactionRule {
+	key = strings.ToLower(key)
+	for _, rule := range p.Rules {
+		for _, glob := range rule.Keys {
+			if ok, _ := path.Match(glob, key); ok {
+				return rule
+			}
+		}
+	}
+	return nil
+}
+
+// redactString applies the pattern rules to s
+func (p *RedactionPolicy) redactString(s string) string {
+	for _, rule := range p.Rules {
+		if rule.pattern == nil {
+			continue
+		}
+		s = rule.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			rule.hits.Add(1)
+			if rule.Strategy == RedactRemove {
+				return ""
+			}
+			return rule.apply(match)
+		})
+	}
+	return s
+}
+
+// apply redacts s with the rule's strategy (other than RedactRemove)
+func (r *RedactionRule) apply(s string) string {
+	switch r.Strategy {
+	case RedactHash:
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	case RedactMask:
+		runes := []rune(s)
+		keep := 4
+		if len(runes) <= keep*2 {
+			keep = 0
+		}
+		return strings.Repeat("*", len(runes)-keep) + string(runes[len(runes)-keep:])
+	}
+	return r.Replacement
+}
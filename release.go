@@ -0,0 +1,143 @@
+package errorid
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+)
+
+// FirstSeen records the build in which an error fingerprint first occurred
+type FirstSeen struct {
+	Fingerprint string    `json:"fingerprint"`
+	Build       string    `json:"build"`    // BuildInfo.String() of the first occurrence
+	ErrorID     string    `json:"error_id"` // First error with the fingerprint
+	Time        time.Time `json:"time"`
+}
+
+// ReleaseIndex is implemented by stores that track the build each error
+// fingerprint was first seen in (MemoryStore, SQLStore with
+// FirstSeenTable), so release managers can list what a deployment
+// introduced. Only errors with a Build are tracked
+type ReleaseIndex interface {
+	// FirstSeen returns the first occurrence of fingerprint, or ErrNotFound
+	FirstSeen(ctx context.Context, fingerprint string) (FirstSeen, error)
+	
+	// NewIn returns the fingerprints first seen in build, oldest first:
+	// errors no earlier build produced
+	NewIn(ctx context.Context, build string) ([]FirstSeen, error)
+}
+
+// firstSeenOf returns the first-seen record err would create, ok false if
+// it has no build
+func firstSeenOf(err *ErrorWithID) (FirstSeen, bool) {
+	if err.Build == nil {
+		return FirstSeen{}, false
+	}
+	return FirstSeen{
+		Fingerprint: err.Fingerprint(),
+		Build:       err.Build.String(),
+		ErrorID:     err.ID,
+		Time:        time.Unix(err.Timestamp, 0),
+	}, true
+}
+
+// FirstSeen implements ReleaseIndex
+func (s *MemoryStore) FirstSeen(ctx context.Context, fingerprint string) (FirstSeen, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if first, ok := s.firstSeen[fingerprint]; ok {
+		return first, nil
+	}
+	return FirstSeen{}, ErrNotFound
+}
+
+// NewIn implements ReleaseIndex
+func (s *MemoryStore) NewIn(ctx context.Context, build string) ([]FirstSeen, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var introduced []FirstSeen
+	for _, first := range s.firstSeen {
+		if first.Build == build {
+			introduced = append(introduced, first)
+		}
+	}
+	sort.Slice(introduced, func(i, j int) bool {
+		return introduced[i].Time.Before(introduced[j].Time)
+	})
+	return introduced, nil
+}
+
+// recordFirstSeen adds err's fingerprint to FirstSeenTable if it is new
+// It always writes through DB, outside any SaveTx transaction: a failed
+// INSERT of a fingerprint recorded concurrently would abort a PostgreSQL
+// transaction, poisoning the caller's business data
+func (s *SQLStore) recordFirstSeen(ctx context.Context, err *ErrorWithID) error {
+	first, ok := firstSeenOf(err)
+	if !ok || s.FirstSeenTable == "" {
+		return nil
+	}
+	
+	var build string
+	scanErr := s.DB.QueryRowContext(ctx, s.firstSeenQuery("SELECT build FROM %s WHERE fingerprint = ?"), first.Fingerprint).Scan(&build)
+	if scanErr == nil {
+		return nil
+	}
+	if !errors.Is(scanErr, sql.ErrNoRows) {
+		return scanErr
+	}
+	
+	_, execErr := s.DB.ExecContext(ctx, s.firstSeenQuery("INSERT INTO %s (fingerprint, build, error_id, timestamp) VALUES (?, ?, ?, ?)"),
+		first.Fingerprint, first.Build, first.ErrorID, err.Timestamp)
+	if execErr != nil {
+		// Another process may have recorded it first
+		if s.DB.QueryRowContext(ctx, s.firstSeenQuery("SELECT build FROM %s WHERE fingerprint = ?"), first.Fingerprint).Scan(&build) == nil {
+			return nil
+		}
+	}
+	return execErr
+}
+
+// FirstSeen implements ReleaseIndex (needs FirstSeenTable)
+func (s *SQLStore) FirstSeen(ctx context.Context, fingerprint string) (FirstSeen, error) {
+	first := FirstSeen{Fingerprint: fingerprint}
+	if s.FirstSeenTable == "" {
+		return first, ErrNotFound
+	}
+	
+	var timestamp int64
+	err := s.DB.QueryRowContext(ctx, s.firstSeenQuery("SELECT build, error_id, timestamp FROM %s WHERE fingerprint = ?"), fingerprint).
+		Scan(&first.Build, &first.ErrorID, &timestamp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return first, ErrNotFound
+	}
+	first.Time = time.Unix(timestamp, 0)
+	return first, err
+}
+
+// NewIn implements ReleaseIndex (needs FirstSeenTable)
+func (s *SQLStore) NewIn(ctx context.Context, build string) ([]FirstSeen, error) {
+	if s.FirstSeenTable == "" {
+		return nil, nil
+	}
+	rows, err := s.DB.QueryContext(ctx, s.firstSeenQuery("SELECT fingerprint, error_id, timestamp FROM %s WHERE build = ? ORDER BY timestamp"), build)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var introduced []FirstSeen
+	for rows.Next() {
+		first := FirstSeen{Build: build}
+		var timestamp int64
+		if err := rows.Scan(&first.Fingerprint, &first.ErrorID, &timestamp); err != nil {
+			return nil, err
+		}
+		first.Time = time.Unix(timestamp, 0)
+		introduced = append(introduced, first)
+	}
+	return introduced, rows.Err()
+}
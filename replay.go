@@ -0,0 +1,147 @@
+package errorid
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// MaxCapturedBody is the largest request body kept for replay
+const MaxCapturedBody = 64 << 10
+
+// maxCaptures is how many captured requests a handler keeps for replay
+const maxCaptures = 100
+
+// requestKey is the context key for the captured request
+type requestKey struct{}
+
+// CapturedRequest is a request kept with its errors (Config.CaptureRequests)
+// so ReplayHandler can re-issue it
+type CapturedRequest struct {
+	Method    string      `json:"method"`
+	URL       string      `json:"url"` // Path and query
+	Host      string      `json:"host"`
+	Header    http.Header `json:"header"`
+	Body      []byte      `json:"body,omitempty"`
+	Truncated bool        `json:"truncated,omitempty"` // Body exceeded MaxCapturedBody, can't replay
+}
+
+// captureRequest buffers the body of r and returns r with its capture in
+// the context. The handler still reads the whole body
+func captureRequest(r *http.Request) *http.Request {
+	captured := &CapturedRequest{
+		Method: r.Method,
+		URL:    r.URL.RequestURI(),
+		Host:   r.Host,
+		Header: r.Header.Clone(),
+	}
+	
+	if r.Body != nil && r.Body != http.NoBody {
+		body, _ := io.ReadAll(io.LimitReader(r.Body, MaxCapturedBody+1))
+		if len(body) > MaxCapturedBody {
+			captured.Body, captured.Truncated = body[:MaxCapturedBody], true
+		} else {
+			captured.Body = body
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	}
+	
+	return r.WithContext(context.WithValue(r.Context(), requestKey{}, captured))
+}
+
+// capturedFrom returns the captured request of ctx, or nil
+func capturedFrom(ctx context.Context) *CapturedRequest {
+	if ctx == nil {
+		return nil
+	}
+	captured, _ := ctx.Value(requestKey{}).(*CapturedRequest)
+	return captured
+}
+
+// captureLog keeps the requests of the latest errors by ID
+type captureLog struct {
+	mu    sync.Mutex
+	byID  map[string]*CapturedRequest
+	order []string
+}
+
+func newCaptureLog() *captureLog {
+	return &captureLog{byID: make(map[string]*CapturedRequest)}
+}
+
+// add records the request of error id, forgetting the oldest past maxCaptures
+func (l *captureLog) add(id string, captured *CapturedRequest) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.byID[id]; ok {
+		return
+	}
+	if len(l.order) == maxCaptures {
+		delete(l.byID, l.order[0])
+		l.order = l.order[1:]
+	}
+	l.byID[id] = captured
+	l.order = append(l.order, id)
+}
+
+// get returns the request of error id, or nil
+func (l *captureLog) get(id string) *CapturedRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.byID[id]
+}
+
+// ReplayHandler serves the requests captured with errors
+// (Config.CaptureRequests) to shorten the reproduce-fix loop:
+//
+//	GET  ?id=ERR-...   the captured request as JSON
+//	POST ?id=ERR-...   re-issues it against target, returning its response
+//
+// It only works in the "development" Environment and answers 404
+// otherwise. Mount it on the local server only:
+//
+//	mux.Handle("/debug/errorid/replay", handler.ReplayHandler(mux))
+func (h *Handler) ReplayHandler(target http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.captures == nil {
+			http.NotFound(w, r)
+			return
+		}
+		
+		id := r.URL.Query().Get("id")
+		captured := h.captures.get(id)
+		if captured == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no captured request for " + id})
+			return
+		}
+		
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, captured)
+		case http.MethodPost:
+			if captured.Truncated {
+				writeJSON(w, http.StatusConflict, map[string]string{"error": "request body too large to replay"})
+				return
+			}
+			req, err := http.NewRequestWithContext(r.Context(), captured.Method, captured.URL, bytes.NewReader(captured.Body))
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			req.Header = captured.Header.Clone()
+			req.Header.Set("X-Errorid-Replay", id)
+			req.Host = captured.Host
+			req.RequestURI = captured.URL
+			req.RemoteAddr = r.RemoteAddr
+			target.ServeHTTP(w, req)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package errorid
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryHint tells clients whether and when to retry a failed request
+type RetryHint struct {
+	Retryable bool
+	After     time.Duration // Suggested wait, 0 if none
+}
+
+// RetryHinter is implemented by errors that know their retry behavior
+// ok is false when the error has no opinion
+type RetryHinter interface {
+	RetryHint() (hint RetryHint, ok bool)
+}
+
+// retryHint returns the hint for err: from the first RetryHinter in the
+// chain, else Config.RetryHints for its category
+func (h *Handler) retryHint(err *ErrorWithID) (RetryHint, bool) {
+	var hinter RetryHinter
+	if errors.As(err.Original, &hinter) {
+		if hint, ok := hinter.RetryHint(); ok {
+			return hint, true
+		}
+	}
+	
+	hint, ok := h.config.RetryHints[err.Category]
+	return hint, ok && err.Category != ""
+}
@@ -0,0 +1,61 @@
+package errorid
+
+import (
+	mrand "math/rand/v2"
+	"strings"
+)
+
+// Sample returns an Interceptor that reports (logs and runs OnError for)
+// only a fraction rate of errors, between 0 and 1. Critical errors are
+// always reported. Skipped errors still get their ID and are returned
+// to the caller
+func Sample(rate float64) Interceptor {
+	return func(next WrapFunc) WrapFunc {
+		return func(err *ErrorWithID) *ErrorWithID {
+			if isCritical(err) || mrand.Float64() < rate {
+				return next(err)
+			}
+			return err
+		}
+	}
+}
+
+// SampleRule is a sampling rate for errors of a Category and/or whose
+// Context starts with ContextPrefix (empty fields match anything)
+type SampleRule struct {
+	Category      string
+	ContextPrefix string
+	Rate          float64
+}
+
+// matches reports whether err falls under r
+func (r SampleRule) matches(err *ErrorWithID) bool {
+	return (r.Category == "" || r.Category == err.Category) &&
+		strings.HasPrefix(err.Context, r.ContextPrefix)
+}
+
+// SampleBy is Sample with rates per category or context prefix: the
+// first matching rule gives the rate, fallback applies otherwise
+//
+//	errorid.SampleBy(1, // everything else
+//	    errorid.SampleRule{ContextPrefix: "cache miss", Rate: 0.01},
+//	    errorid.SampleRule{Category: "payment", Rate: 1},
+//	)
+func SampleBy(fallback float64, rules ...SampleRule) Interceptor {
+	return func(next WrapFunc) WrapFunc {
+		return func(err *ErrorWithID) *ErrorWithID {
+			rate := fallback
+			for _, rule := range rules {
+				if rule.matches(err) {
+					rate = rule.Rate
+					break
+				}
+			}
+			
+			if isCritical(err) || mrand.Float64() < rate {
+				return next(err)
+			}
+			return err
+		}
+	}
+}
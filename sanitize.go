@@ -0,0 +1,132 @@
+package errorid
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// PanicSanitizer cleans recovered panic values for Config.SanitizePanic,
+// since raw values sometimes print entire request objects:
+//
+//	errorid.Config{SanitizePanic: errorid.PanicSanitizer{
+//		MaxLength:      512,
+//		StripAddresses: true,
+//		RedactFields:   []string{"Password", "Authorization", "Token"},
+//	}.Sanitize}
+type PanicSanitizer struct {
+	// MaxLength truncates the formatted value (0 = no limit)
+	MaxLength int
+	
+	// StripAddresses replaces pointer addresses (0xc000123456) with "0x…",
+	// so the same panic always reads the same
+	StripAddresses bool
+	
+	// RedactFields are struct field and map key names (case-insensitive)
+	// whose values print as [REDACTED], at any depth
+	RedactFields []string
+}
+
+// addressPattern matches pointer addresses in formatted values
+var addressPattern = regexp.MustCompile(`\b0x[0-9a-fA-F]{6,}\b`)
+
+// maxSanitizeDepth bounds the walk of nested values
+const maxSanitizeDepth = 8
+
+// Sanitize formats a panic value according to s
+func (s PanicSanitizer) Sanitize(value interface{}) string {
+	var text string
+	if len(s.RedactFields) > 0 {
+		redact := make(map[string]bool, len(s.RedactFields))
+		for _, name := range s.RedactFields {
+			redact[strings.ToLower(name)] = true
+		}
+		var b strings.Builder
+		formatRedacted(&b, reflect.ValueOf(value), redact, 0)
+		text = b.String()
+	} else {
+		text = fmt.Sprint(value)
+	}
+	
+	if s.StripAddresses {
+		text = addressPattern.ReplaceAllString(text, "0x…")
+	}
+	if s.MaxLength > 0 {
+		text = truncate(text, s.MaxLength)
+	}
+	return text
+}
+
+// formatRedacted writes v like %+v, printing the values of redacted
+// struct fields and map keys as [REDACTED]
+func formatRedacted(b *strings.Builder, v reflect.Value, redact map[string]bool, depth int) {
+	if !v.IsValid() {
+		b.WriteString("<nil>")
+		return
+	}
+	if depth > maxSanitizeDepth {
+		b.WriteString("…")
+		return
+	}
+	
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("<nil>")
+			return
+		}
+		if v.Kind() == reflect.Pointer {
+			b.WriteByte('&')
+		}
+		formatRedacted(b, v.Elem(), redact, depth+1)
+	case reflect.Struct:
+		b.WriteByte('{')
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			name := v.Type().Field(i).Name
+			b.WriteString(name + ":")
+			if redact[strings.ToLower(name)] {
+				b.WriteString("[REDACTED]")
+				continue
+			}
+			formatRedacted(b, v.Field(i), redact, depth+1)
+		}
+		b.WriteByte('}')
+	case reflect.Map:
+		b.WriteString("map[")
+		keys := v.MapKeys()
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			name := fmt.Sprint(key)
+			b.WriteString(name + ":")
+			if redact[strings.ToLower(name)] {
+				b.WriteString("[REDACTED]")
+				continue
+			}
+			formatRedacted(b, v.MapIndex(key), redact, depth+1)
+		}
+		b.WriteByte(']')
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			fmt.Fprint(b, v)
+			return
+		}
+		b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			formatRedacted(b, v.Index(i), redact, depth+1)
+		}
+		b.WriteByte(']')
+	default:
+		// fmt prints reflect.Values as their underlying value, including
+		// unexported fields
+		fmt.Fprint(b, v)
+	}
+}
@@ -0,0 +1,170 @@
+package errorid
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// SearchIndex is an in-memory inverted index over the most recent errors,
+// for local search (debug endpoints, CLIs, edge deployments) without an
+// external store. It indexes the ID, incident ID, context words, error
+// code, category and detail values; details are also indexed as
+// "key=value" terms
+//
+//	index := errorid.NewSearchIndex(10000)
+//	errorid.Configure(errorid.Config{OnError: index.OnError})
+//	matches := index.Search("checkout user_id=42")
+type SearchIndex struct {
+	mu       sync.Mutex
+	capacity int
+	next     uint64                         // sequence number of the next error
+	errors   map[uint64]*ErrorWithID        // indexed errors by sequence
+	terms    map[uint64][]string            // terms of each indexed error
+	postings map[string]map[uint64]struct{} // term -> sequences
+}
+
+// NewSearchIndex returns an index keeping the latest capacity errors
+func NewSearchIndex(capacity int) *SearchIndex {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &SearchIndex{
+		capacity: capacity,
+		errors:   make(map[uint64]*ErrorWithID),
+		terms:    make(map[uint64][]string),
+		postings: make(map[string]map[uint64]struct{}),
+	}
+}
+
+// OnError is a Config.OnError callback adding err to the index
+func (s *SearchIndex) OnError(err *ErrorWithID) {
+	s.Add(err)
+}
+
+// Add indexes err, evicting the oldest error when full
+func (s *SearchIndex) Add(err *ErrorWithID) {
+	if err == nil {
+		return
+	}
+	terms := searchTerms(err)
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	seq := s.next
+	s.next++
+	s.errors[seq] = err
+	s.terms[seq] = terms
+	for _, term := range terms {
+		if s.postings[term] == nil {
+			s.postings[term] = make(map[uint64]struct{})
+		}
+		s.postings[term][seq] = struct{}{}
+	}
+	
+	if len(s.errors) > s.capacity {
+		s.evict(seq - uint64(s.capacity))
+	}
+}
+
+// evict removes the error with sequence seq, s.mu must be held
+func (s *SearchIndex) evict(seq uint64) {
+	for _, term := range s.terms[seq] {
+		delete(s.postings[term], seq)
+		if len(s.postings[term]) == 0 {
+			delete(s.postings, term)
+		}
+	}
+	delete(s.errors, seq)
+	delete(s.terms, seq)
+}
+
+// Search returns the indexed errors matching every term of query, newest
+// first. Terms are case-insensitive words or "key=value" detail matches
+// An empty query matches nothing
+func (s *SearchIndex) Search(query string) []*ErrorWithID {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		if strings.Contains(field, "=") {
+			terms = append(terms, strings.ToLower(field))
+		} else {
+			terms = append(terms, searchWords(field)...)
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	// Intersect starting from the rarest term
+	sort.Slice(terms, func(i, j int) bool { return len(s.postings[terms[i]]) < len(s.postings[terms[j]]) })
+	
+	var seqs []uint64
+	for seq := range s.postings[terms[0]] {
+		matched := true
+		for _, term := range terms[1:] {
+			if _, ok := s.postings[term][seq]; !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] > seqs[j] })
+	
+	results := make([]*ErrorWithID, len(seqs))
+	for i, seq := range seqs {
+		results[i] = s.errors[seq]
+	}
+	return results
+}
+
+// Len returns the number of indexed errors
+func (s *SearchIndex) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.errors)
+}
+
+// searchTerms returns the distinct terms indexed for err
+func searchTerms(err *ErrorWithID) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	
+	add(strings.ToLower(err.ID))
+	add(strings.ToLower(err.IncidentID))
+	for _, text := range []string{err.Context, ErrorCode(err.Original), err.Category} {
+		for _, word := range searchWords(text) {
+			add(word)
+		}
+	}
+	for key, value := range err.Details {
+		text := fmt.Sprint(value)
+		add(strings.ToLower(key + "=" + text))
+		for _, word := range searchWords(text) {
+			add(word)
+		}
+	}
+	return terms
+}
+
+// searchWords splits text into lowercase words of letters and digits
+// Words keep '-' and '_' so IDs and codes stay whole
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+	})
+}
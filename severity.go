@@ -0,0 +1,81 @@
+package errorid
+
+import (
+	"errors"
+	"strings"
+)
+
+// Severity ranks how urgent an error is
+// The zero value means unset; wrapped errors default to SeverityError
+type Severity int
+
+const (
+	SeverityInfo Severity = iota + 1
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+// String returns the lowercase severity name
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "unset"
+}
+
+// ParseSeverity parses a severity name as returned by String
+func ParseSeverity(name string) (Severity, bool) {
+	for s := SeverityInfo; s <= SeverityCritical; s++ {
+		if strings.EqualFold(name, s.String()) {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// SeverityCarrier is implemented by errors that know their severity
+type SeverityCarrier interface {
+	ErrorSeverity() Severity
+}
+
+// CategoryCarrier is implemented by errors that belong to a category
+// such as "database" or "payment"
+type CategoryCarrier interface {
+	ErrorCategory() string
+}
+
+// severityOf returns the severity of the first SeverityCarrier in err's
+// chain that sets one, or SeverityError
+func severityOf(err error) Severity {
+	var carrier SeverityCarrier
+	if errors.As(err, &carrier) {
+		if s := carrier.ErrorSeverity(); s != 0 {
+			return s
+		}
+	}
+	return SeverityError
+}
+
+// categoryOf returns the category of the first CategoryCarrier in err's
+// chain, or ""
+func categoryOf(err error) string {
+	var carrier CategoryCarrier
+	if errors.As(err, &carrier) {
+		return carrier.ErrorCategory()
+	}
+	return ""
+}
+
+// isCritical reports whether err must bypass sampling, rate limits and
+// async callback caps, so paging isn't delayed or lost
+func isCritical(err *ErrorWithID) bool {
+	return err.Severity >= SeverityCritical
+}
@@ -0,0 +1,169 @@
+package errorid
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SignatureHeader carries the detached signature of a signed error
+// response body (Config.ResponseSigner)
+const SignatureHeader = "Error-Signature"
+
+// Signature algorithms of HMACSigner and Ed25519Signer
+const (
+	SignatureHMACSHA256 = "hmac-sha256"
+	SignatureEd25519    = "ed25519"
+)
+
+// ErrBadSignature is returned by Signature.Verify when the body doesn't
+// match the signature
+var ErrBadSignature = errors.New("errorid: response signature mismatch")
+
+// ResponseSigner signs error response bodies (Config.ResponseSigner)
+type ResponseSigner interface {
+	SignResponse(body []byte) (Signature, error)
+}
+
+// Signature is a detached signature of a response body, sent as
+// Error-Signature: keyid="k1", alg="ed25519", sig="<base64url>"
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Value     []byte
+}
+
+// String formats the signature as the header value
+func (s Signature) String() string {
+	return fmt.Sprintf("keyid=%q, alg=%q, sig=%q", s.KeyID, s.Algorithm, base64.RawURLEncoding.EncodeToString(s.Value))
+}
+
+// ParseSignature parses an Error-Signature header value
+func ParseSignature(header string) (Signature, error) {
+	var s Signature
+	for _, part := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return Signature{}, fmt.Errorf("errorid: malformed signature parameter %q", part)
+		}
+		value = strings.Trim(value, `"`)
+	
+		switch name {
+		case "keyid":
+			s.KeyID = value
+		case "alg":
+			s.Algorithm = value
+		case "sig":
+			sig, err := base64.RawURLEncoding.DecodeString(value)
+			if err != nil {
+				return Signature{}, fmt.Errorf("errorid: decoding signature: %w", err)
+			}
+			s.Value = sig
+		}
+	}
+	if s.Algorithm == "" || s.Value == nil {
+		return Signature{}, errors.New("errorid: signature without alg or sig")
+	}
+	return s, nil
+}
+
+// Verify checks the signature of body against key: the shared []byte key
+// for hmac-sha256, an ed25519.PublicKey for ed25519. Pick the key by
+// KeyID when rotating keys
+func (s Signature) Verify(body []byte, key interface{}) error {
+	switch s.Algorithm {
+	case SignatureHMACSHA256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("errorid: %s needs a []byte key, got %T", s.Algorithm, key)
+		}
+		if !hmac.Equal(s.Value, hmacSHA256(secret, body)) {
+			return ErrBadSignature
+		}
+	case SignatureEd25519:
+		public, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("errorid: %s needs an ed25519.PublicKey, got %T", s.Algorithm, key)
+		}
+		if !ed25519.Verify(public, body, s.Value) {
+			return ErrBadSignature
+		}
+	default:
+		return fmt.Errorf("errorid: unknown signature algorithm %q", s.Algorithm)
+	}
+	return nil
+}
+
+// VerifyResponse checks the Error-Signature of a response against its
+// body, already read (e.g. the bytes given to ParseResponse)
+func VerifyResponse(header http.Header, body []byte, key interface{}) error {
+	value := header.Get(SignatureHeader)
+	if value == "" {
+		return fmt.Errorf("errorid: response has no %s header", SignatureHeader)
+	}
+	s, err := ParseSignature(value)
+	if err != nil {
+		return err
+	}
+	return s.Verify(body, key)
+}
+
+// HMACSigner signs with HMAC-SHA256 and a key shared with verifiers
+// (e.g. an API gateway)
+type HMACSigner struct {
+	KeyID string
+	Key   []byte
+}
+
+// SignResponse implements ResponseSigner
+func (s HMACSigner) SignResponse(body []byte) (Signature, error) {
+	if len(s.Key) == 0 {
+		return Signature{}, errors.New("errorid: HMACSigner without a key")
+	}
+	return Signature{KeyID: s.KeyID, Algorithm: SignatureHMACSHA256, Value: hmacSHA256(s.Key, body)}, nil
+}
+
+// Ed25519Signer signs with an Ed25519 private key; clients verify with the
+// published public key
+type Ed25519Signer struct {
+	KeyID string
+	Key   ed25519.PrivateKey
+}
+
+// SignResponse implements ResponseSigner
+func (s Ed25519Signer) SignResponse(body []byte) (Signature, error) {
+	if len(s.Key) != ed25519.PrivateKeySize {
+		return Signature{}, errors.New("errorid: Ed25519Signer without a valid key")
+	}
+	return Signature{KeyID: s.KeyID, Algorithm: SignatureEd25519, Value: ed25519.Sign(s.Key, body)}, nil
+}
+
+// hmacSHA256 is the HMAC-SHA256 of body
+func hmacSHA256(key, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// signResponse sets the Error-Signature header for body with
+// Config.ResponseSigner. Failures are logged and the response goes out
+// unsigned
+func (h *Handler) signResponse(w http.ResponseWriter, body []byte) {
+	if h.config.ResponseSigner == nil {
+		return
+	}
+	
+	s, err := h.config.ResponseSigner.SignResponse(body)
+	if err != nil {
+		if h.config.Logger != nil {
+			h.config.Logger.Info(fmt.Sprintf("signing error response failed: %v", err))
+		}
+		return
+	}
+	w.Header().Set(SignatureHeader, s.String())
+}
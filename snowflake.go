@@ -0,0 +1,339 @@
+package errorid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// SnowflakeNodes is the number of node IDs a NodeLeaser hands out (0 to
+// SnowflakeNodes-1)
+const SnowflakeNodes = 1 << snowflakeNodeBits
+
+// Bit widths of the node and per-millisecond sequence parts of snowflake IDs
+const (
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+)
+
+// defaultLeaseTTL is the node lease duration of NewSnowflakeGenerator
+const defaultLeaseTTL = time.Minute
+
+// snowflakeEpoch is time zero of the 41-bit millisecond timestamp
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	// ErrNoFreeNode is returned by NodeLeaser.AcquireNode when every node
+	// ID is leased
+	ErrNoFreeNode = errors.New("errorid: no free snowflake node ID")
+	
+	// ErrLeaseLost is returned by NodeLeaser.RenewNode when the lease
+	// expired and the node ID may have been given to another owner
+	ErrLeaseLost = errors.New("errorid: snowflake node lease lost")
+)
+
+// NodeLeaser hands out node IDs for SnowflakeGenerator, so replicas get
+// distinct nodes without assigning them in config. MemoryStore (one
+// process) and SQLStore with NodeTable (shared database) implement it;
+// implement it on Redis or etcd with SET NX plus an expiry
+type NodeLeaser interface {
+	// AcquireNode leases a free node ID to owner for ttl
+	AcquireNode(ctx context.Context, owner string, ttl time.Duration) (int, error)
+	
+	// RenewNode extends owner's lease on node by ttl, or returns
+	// ErrLeaseLost if owner no longer holds it
+	RenewNode(ctx context.Context, node int, owner string, ttl time.Duration) error
+	
+	// ReleaseNode gives the node up if owner holds it
+	ReleaseNode(ctx context.Context, node int, owner string) error
+}
+
+// SnowflakeGenerator generates error IDs unique across replicas without
+// relying on randomness: ERR-YYYYMMDD- followed by 16 hex digits of a
+// millisecond timestamp, the node ID leased at startup and a sequence.
+// The lease is renewed in the background; while it is lost, Next falls
+// back to GenerateErrorID. Use it as Config.IDGenerator:
+//
+//	gen, err := errorid.NewSnowflakeGenerator(ctx, store, time.Minute)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer gen.Close(context.Background())
+//	handler := errorid.New(errorid.Config{IDGenerator: gen.Next})
+type SnowflakeGenerator struct {
+	leaser NodeLeaser
+	owner  string
+	ttl    time.Duration
+	now    func() time.Time // time.Now, replaced in tests
+	
+	mu      sync.Mutex
+	node    int
+	expires time.Time // end of the current lease
+	last    int64     // milliseconds since snowflakeEpoch of the last ID
+	seq     int64
+	
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSnowflakeGenerator leases a node ID from leaser for ttl (default 1
+// minute) and renews it every third of ttl until Close
+func NewSnowflakeGenerator(ctx context.Context, leaser NodeLeaser, ttl time.Duration) (*SnowflakeGenerator, error) {
+	return newSnowflakeGenerator(ctx, leaser, ttl, time.Now)
+}
+
+// newSnowflakeGenerator is NewSnowflakeGenerator reading time from now
+func newSnowflakeGenerator(ctx context.Context, leaser NodeLeaser, ttl time.Duration, now func() time.Time) (*SnowflakeGenerator, error) {
+	if ttl <= 0 {
+		ttl = defaultLeaseTTL
+	}
+	
+	g := &SnowflakeGenerator{
+		leaser:  leaser,
+		owner:   leaseOwner(),
+		ttl:     ttl,
+		now:     now,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	
+	start := now()
+	node, err := leaser.AcquireNode(ctx, g.owner, ttl)
+	if err != nil {
+		return nil, err
+	}
+	g.node, g.expires = node, start.Add(ttl)
+	
+	go g.renew()
+	return g, nil
+}
+
+// leaseOwner identifies this process in node leases
+func leaseOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), MathRandFallback(time.Now()))
+}
+
+// Node returns the node ID currently leased
+func (g *SnowflakeGenerator) Node() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.node
+}
+
+// Next returns a new ID
+func (g *SnowflakeGenerator) Next() string {
+	now := g.now()
+	
+	g.mu.Lock()
+	if now.After(g.expires) {
+		g.mu.Unlock()
+		return GenerateErrorID()
+	}
+	
+	// Never go back in time, even if the clock does
+	ms := now.Sub(snowflakeEpoch).Milliseconds()
+	if ms < g.last {
+		ms = g.last
+	}
+	if ms == g.last {
+		g.seq++
+		if g.seq == 1<<snowflakeSeqBits {
+			// Sequence exhausted: borrow the next millisecond
+			ms++
+			g.seq = 0
+		}
+	} else {
+		g.seq = 0
+	}
+	g.last = ms
+	id := ms<<(snowflakeNodeBits+snowflakeSeqBits) | int64(g.node)<<snowflakeSeqBits | g.seq
+	g.mu.Unlock()
+	
+	return fmt.Sprintf("ERR-%s-%016x", now.Format("20060102"), id)
+}
+
+// Close stops renewing and releases the node ID
+// Next keeps working, falling back to GenerateErrorID
+func (g *SnowflakeGenerator) Close(ctx context.Context) error {
+	var err error
+	g.closeOnce.Do(func() {
+		close(g.done)
+		<-g.stopped
+		
+		g.mu.Lock()
+		node := g.node
+		g.expires = time.Time{}
+		g.mu.Unlock()
+		err = g.leaser.ReleaseNode(ctx, node, g.owner)
+	})
+	return err
+}
+
+// renew extends the lease until Close, leasing a new node if it was lost
+func (g *SnowflakeGenerator) renew() {
+	defer close(g.stopped)
+	
+	ticker := time.NewTicker(g.ttl / 3)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-ticker.C:
+		}
+		g.renewLease()
+	}
+}
+
+// renewLease extends the lease once, leasing a new node if it was lost
+func (g *SnowflakeGenerator) renewLease() {
+	ctx, cancel := context.WithTimeout(context.Background(), g.ttl/3)
+	defer cancel()
+	
+	start := g.now()
+	node := g.Node()
+	err := g.leaser.RenewNode(ctx, node, g.owner, g.ttl)
+	if errors.Is(err, ErrLeaseLost) {
+		node, err = g.leaser.AcquireNode(ctx, g.owner, g.ttl)
+	}
+	
+	// On other failures the lease runs out and Next falls back
+	if err == nil {
+		g.mu.Lock()
+		g.node, g.expires = node, start.Add(g.ttl)
+		g.mu.Unlock()
+	}
+}
+
+// nodeLease is a node ID held by owner until expires
+type nodeLease struct {
+	owner   string
+	expires time.Time
+}
+
+// AcquireNode implements NodeLeaser within one process
+func (s *MemoryStore) AcquireNode(ctx context.Context, owner string, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.leases == nil {
+		s.leases = make(map[int]nodeLease)
+	}
+	now := time.Now()
+	for node := 0; node < SnowflakeNodes; node++ {
+		if lease, ok := s.leases[node]; !ok || now.After(lease.expires) {
+			s.leases[node] = nodeLease{owner: owner, expires: now.Add(ttl)}
+			return node, nil
+		}
+	}
+	return 0, ErrNoFreeNode
+}
+
+// RenewNode implements NodeLeaser
+func (s *MemoryStore) RenewNode(ctx context.Context, node int, owner string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	now := time.Now()
+	if lease, ok := s.leases[node]; !ok || lease.owner != owner || now.After(lease.expires) {
+		return ErrLeaseLost
+	}
+	s.leases[node] = nodeLease{owner: owner, expires: now.Add(ttl)}
+	return nil
+}
+
+// ReleaseNode implements NodeLeaser
+func (s *MemoryStore) ReleaseNode(ctx context.Context, node int, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if lease, ok := s.leases[node]; ok && lease.owner == owner {
+		delete(s.leases, node)
+	}
+	return nil
+}
+
+// nodeQuery is query for NodeTable
+func (s *SQLStore) nodeQuery(format string) string {
+	return s.placeholders(fmt.Sprintf(format, s.NodeTable))
+}
+
+// AcquireNode implements NodeLeaser (needs NodeTable): it takes the first
+// node with no row or an expired one. A node claimed concurrently by
+// another replica fails its conditional UPDATE or INSERT and is skipped
+func (s *SQLStore) AcquireNode(ctx context.Context, owner string, ttl time.Duration) (int, error) {
+	if s.NodeTable == "" {
+		return 0, errors.New("errorid: SQLStore.NodeTable is not set")
+	}
+	
+	now := time.Now()
+	rows, err := s.DB.QueryContext(ctx, s.nodeQuery("SELECT node, expires FROM %s"))
+	if err != nil {
+		return 0, err
+	}
+	expiries := make(map[int]int64)
+	for rows.Next() {
+		var node int
+		var expires int64
+		if err := rows.Scan(&node, &expires); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expiries[node] = expires
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	
+	until := now.Add(ttl).UnixMilli()
+	for node := 0; node < SnowflakeNodes; node++ {
+		expires, exists := expiries[node]
+		if exists && expires >= now.UnixMilli() {
+			continue
+		}
+		
+		if exists {
+			result, err := s.DB.ExecContext(ctx, s.nodeQuery("UPDATE %s SET owner = ?, expires = ? WHERE node = ? AND expires = ?"), owner, until, node, expires)
+			if err != nil {
+				return 0, err
+			}
+			if n, _ := result.RowsAffected(); n == 1 {
+				return node, nil
+			}
+			continue
+		}
+		
+		// Fails on the primary key if another replica inserted first
+		if _, err := s.DB.ExecContext(ctx, s.nodeQuery("INSERT INTO %s (node, owner, expires) VALUES (?, ?, ?)"), node, owner, until); err == nil {
+			return node, nil
+		}
+	}
+	return 0, ErrNoFreeNode
+}
+
+// RenewNode implements NodeLeaser (needs NodeTable)
+func (s *SQLStore) RenewNode(ctx context.Context, node int, owner string, ttl time.Duration) error {
+	now := time.Now()
+	result, err := s.DB.ExecContext(ctx, s.nodeQuery("UPDATE %s SET expires = ? WHERE node = ? AND owner = ? AND expires >= ?"),
+		now.Add(ttl).UnixMilli(), node, owner, now.UnixMilli())
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// ReleaseNode implements NodeLeaser (needs NodeTable)
+func (s *SQLStore) ReleaseNode(ctx context.Context, node int, owner string) error {
+	_, err := s.DB.ExecContext(ctx, s.nodeQuery("DELETE FROM %s WHERE node = ? AND owner = ?"), node, owner)
+	return err
+}
@@ -0,0 +1,164 @@
+package errorid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ReplayReport is the outcome of WriteBehindStore.Replay
+type ReplayReport struct {
+	Recovered int // Spooled errors written to the store
+	Dropped   int // Spooled errors older than maxAge or unreadable, deleted
+	Failed    int // Spooled errors the store rejected, kept for the next replay
+}
+
+// Replay writes errors left in SpoolDir by an earlier process (queued but
+// never written, e.g. after a crash) to the store. Call it at startup,
+// before wrapping errors. Errors older than maxAge (0 for no limit) and
+// unreadable files are deleted; errors the store rejects stay spooled and
+// are reported to OnError. Together with SpoolDir this gives at-least-once
+// delivery across restarts
+func (s *WriteBehindStore) Replay(ctx context.Context, maxAge time.Duration) (ReplayReport, error) {
+	var report ReplayReport
+	if s.SpoolDir == "" {
+		return report, nil
+	}
+	
+	entries, err := os.ReadDir(s.SpoolDir)
+	if os.IsNotExist(err) {
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+	
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		
+		file := filepath.Join(s.SpoolDir, entry.Name())
+		record, readErr := readSpooled(file)
+		if readErr != nil || (maxAge > 0 && time.Since(time.Unix(record.Timestamp, 0)) > maxAge) {
+			os.Remove(file)
+			report.Dropped++
+			continue
+		}
+		
+		spooled := record.errorWithID()
+		if saveErr := s.store.Save(ctx, spooled); saveErr != nil {
+			report.Failed++
+			if s.OnError != nil {
+				s.OnError(spooled, saveErr)
+			}
+			continue
+		}
+		os.Remove(file)
+		report.Recovered++
+	}
+	return report, nil
+}
+
+// spool writes err to SpoolDir before it is queued, reporting failures to
+// OnError. Errors without an ID are not spooled
+func (s *WriteBehindStore) spool(err *ErrorWithID) {
+	if spoolErr := spoolWrite(s.SpoolDir, err); spoolErr != nil && s.OnError != nil {
+		s.OnError(err, fmt.Errorf("spool: %w", spoolErr))
+	}
+}
+
+// unspool deletes the spool file of a written error
+func (s *WriteBehindStore) unspool(err *ErrorWithID) {
+	spoolRemove(s.SpoolDir, err)
+}
+
+// spoolWrite writes err to a file in dir, for stores spooling to disk
+// (WriteBehindStore, FailoverStore). Nothing is written without a dir or ID
+func spoolWrite(dir string, err *ErrorWithID) error {
+	file := spoolFile(dir, err)
+	if file == "" {
+		return nil
+	}
+	
+	data, marshalErr := json.Marshal(newBundleError(err))
+	if marshalErr != nil {
+		return marshalErr
+	}
+	if mkdirErr := os.MkdirAll(dir, 0o700); mkdirErr != nil {
+		return mkdirErr
+	}
+	
+	// Write, sync, then rename so Replay never reads a truncated file, and
+	// sync the directory so the rename survives a crash
+	tmp := file + ".tmp"
+	if writeErr := writeSynced(tmp, data); writeErr != nil {
+		os.Remove(tmp)
+		return writeErr
+	}
+	if renameErr := os.Rename(tmp, file); renameErr != nil {
+		return renameErr
+	}
+	return syncDir(dir)
+}
+
+// writeSynced writes data to a new file at path and syncs it to disk
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir syncs a directory's entries to disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	
+	return d.Sync()
+}
+
+// spoolRemove deletes the spool file of err in dir
+func spoolRemove(dir string, err *ErrorWithID) {
+	if file := spoolFile(dir, err); file != "" {
+		os.Remove(file)
+	}
+}
+
+// readSpooled decodes a spool file
+func readSpooled(file string) (bundleError, error) {
+	var record bundleError
+	data, err := os.ReadFile(file)
+	if err == nil {
+		err = json.Unmarshal(data, &record)
+	}
+	return record, err
+}
+
+// spoolFile is the spool path of err in dir, or "" if it isn't spooled
+func spoolFile(dir string, err *ErrorWithID) string {
+	if dir == "" || err.ID == "" {
+		return ""
+	}
+	return filepath.Join(dir, url.PathEscape(err.ID)+".json")
+}
@@ -0,0 +1,196 @@
+package errorid
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TxMode decides what happens to errors saved with SQLStore.SaveTx when
+// the caller's transaction rolls back
+type TxMode int
+
+const (
+	// TxJoin writes the error row inside the transaction: it commits or
+	// rolls back with the business data
+	TxJoin TxMode = iota
+
+	// TxOutbox writes the error outside the transaction, so errors
+	// recorded during a rolled-back transaction are kept
+	TxOutbox
+)
+
+// SQLStore is a Store on database/sql (any driver). Create the table with
+//
+//	CREATE TABLE error_ids (
+//	    id        VARCHAR(64) PRIMARY KEY,
+//	    timestamp BIGINT NOT NULL,
+//	    data      TEXT NOT NULL -- JSON
+//	)
+//
+// Loaded errors carry their message and code but not the original error
+// value
+//
+// With FirstSeenTable set it also implements ReleaseIndex, using
+//
+//	CREATE TABLE error_first_seen (
+//	    fingerprint VARCHAR(64) PRIMARY KEY,
+//	    build       VARCHAR(255) NOT NULL,
+//	    error_id    VARCHAR(64) NOT NULL,
+//	    timestamp   BIGINT NOT NULL
+//	)
+//
+// With NodeTable set it also implements NodeLeaser, using
+//
+//	CREATE TABLE error_nodes (
+//	    node    INT PRIMARY KEY,
+//	    owner   VARCHAR(255) NOT NULL,
+//	    expires BIGINT NOT NULL -- Unix milliseconds
+//	)
+type SQLStore struct {
+	DB    *sql.DB
+	Table string // Default "error_ids"
+	
+	// NumberedPlaceholders uses $1, $2, ... (PostgreSQL) instead of ?
+	NumberedPlaceholders bool
+	
+	// TxMode applies to SaveTx (default TxJoin)
+	TxMode TxMode
+	
+	// FirstSeenTable records the build each fingerprint first occurred in
+	// (see ReleaseIndex), outside SaveTx transactions. Empty disables it
+	FirstSeenTable string
+	
+	// NodeTable holds the snowflake node ID leases (see NodeLeaser)
+	NodeTable string
+}
+
+// execer is the part of *sql.DB and *sql.Tx used for writes
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Save implements Store
+func (s *SQLStore) Save(ctx context.Context, err *ErrorWithID) error {
+	return s.insert(ctx, s.DB, err)
+}
+
+// SaveTx saves err as part of the caller's transaction, or outside it
+// with TxOutbox. Use it from Config.OnError-style code that runs within
+// a business transaction:
+//
+//	tx, _ := db.BeginTx(ctx, nil)
+//	if err := placeOrder(tx); err != nil {
+//	    wrapped := handler.Wrap(err, "place order")
+//	    store.SaveTx(ctx, tx, wrapped)
+//	    tx.Rollback() // the row goes too, unless TxOutbox
+//	}
+func (s *SQLStore) SaveTx(ctx context.Context, tx *sql.Tx, err *ErrorWithID) error {
+	if s.TxMode == TxOutbox || tx == nil {
+		return s.insert(ctx, s.DB, err)
+	}
+	return s.insert(ctx, tx, err)
+}
+
+// insert writes err through db
+func (s *SQLStore) insert(ctx context.Context, db execer, err *ErrorWithID) error {
+	data, marshalErr := json.Marshal(newBundleError(err))
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, execErr := db.ExecContext(ctx, s.query("INSERT INTO %s (id, timestamp, data) VALUES (?, ?, ?)"), err.ID, err.Timestamp, string(data))
+	if execErr != nil {
+		return execErr
+	}
+	return s.recordFirstSeen(ctx, err)
+}
+
+// Load implements Store
+func (s *SQLStore) Load(ctx context.Context, id string) (*ErrorWithID, error) {
+	var data string
+	err := s.DB.QueryRowContext(ctx, s.query("SELECT data FROM %s WHERE id = ?"), id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	var record bundleError
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, fmt.Errorf("errorid: decoding stored error %s: %w", id, err)
+	}
+	return record.errorWithID(), nil
+}
+
+// query fills in the table name and adapts placeholders
+func (s *SQLStore) query(format string) string {
+	table := s.Table
+	if table == "" {
+		table = "error_ids"
+	}
+	return s.placeholders(fmt.Sprintf(format, table))
+}
+
+// firstSeenQuery is query for FirstSeenTable
+func (s *SQLStore) firstSeenQuery(format string) string {
+	return s.placeholders(fmt.Sprintf(format, s.FirstSeenTable))
+}
+
+// placeholders turns ? into $1, $2, ... with NumberedPlaceholders
+func (s *SQLStore) placeholders(q string) string {
+	if !s.NumberedPlaceholders {
+		return q
+	}
+	
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// storedError stands in for the original error of a loaded error
+type storedError struct {
+	message string
+	code    string
+}
+
+func (e *storedError) Error() string     { return e.message }
+func (e *storedError) ErrorCode() string { return e.code }
+
+// errorWithID rebuilds an ErrorWithID from its stored form
+func (r bundleError) errorWithID() *ErrorWithID {
+	severity, _ := ParseSeverity(r.Severity)
+	return &ErrorWithID{
+		ID:          r.ErrorID,
+		Original:    &storedError{message: r.Message, code: r.Code},
+		Context:     r.Context,
+		StackTrace:  r.StackTrace,
+		Origin:      r.Origin,
+		Component:   r.Component,
+		Details:     r.Details,
+		Timestamp:   r.Timestamp,
+		Related:     r.Related,
+		Causes:      storedCauses(r.Causes),
+		Breadcrumbs: r.Breadcrumbs,
+		Trail:       r.Trail,
+		Goroutine:   r.Goroutine,
+		Correlation: r.Correlation,
+		PanicStack:  r.PanicStack,
+		IncidentID:  r.IncidentID,
+		Severity:    severity,
+		Category:    r.Category,
+		fingerprint: r.Fingerprint,
+	}
+}
@@ -0,0 +1,66 @@
+package errorid
+
+import (
+	"strconv"
+	"strings"
+)
+
+// StackFrame is one frame of a captured stack in machine-readable form
+//
+// JSON schema (Config.StackFormat = StackFormatFrames), top frame first:
+//
+//	[{"func": "main.handler", "file": "/app/main.go", "line": 42}, ...]
+type StackFrame struct {
+	Func string `json:"func"` // Fully qualified function name
+	File string `json:"file"` // Absolute source file path
+	Line int    `json:"line"`
+}
+
+// StackFormat selects how stacks appear in logs and responses
+type StackFormat int
+
+const (
+	// StackFormatText keeps stacks as multi-line strings: "stack_trace" and
+	// "panic_stack" in responses and the Logger's stackTrace argument
+	StackFormatText StackFormat = iota
+
+	// StackFormatFrames encodes stacks as []StackFrame: "stack_frames" and
+	// "panic_frames" in responses and in the logged details
+	StackFormatFrames
+)
+
+// Frames returns the wrap-site stack trace as frames (nil if not captured)
+func (e *ErrorWithID) Frames() []StackFrame {
+	return ParseStack(e.StackTrace)
+}
+
+// PanicFrames returns the panic site stack as frames (nil if not a panic)
+func (e *ErrorWithID) PanicFrames() []StackFrame {
+	return ParseStack(e.PanicStack)
+}
+
+// ParseStack parses a stack trace in the format of ErrorWithID.StackTrace
+// ("function\n\tfile:line\n" per frame). Other lines are skipped
+func ParseStack(trace string) []StackFrame {
+	var frames []StackFrame
+	function := ""
+	for _, line := range strings.Split(trace, "\n") {
+		if !strings.HasPrefix(line, "\t") {
+			function = line
+			continue
+		}
+		
+		location := line[1:]
+		i := strings.LastIndex(location, ":")
+		if i < 0 || function == "" {
+			continue
+		}
+		n, err := strconv.Atoi(location[i+1:])
+		if err != nil {
+			continue
+		}
+		frames = append(frames, StackFrame{Func: function, File: location[:i], Line: n})
+		function = ""
+	}
+	return frames
+}
@@ -0,0 +1,73 @@
+package errorid
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Errors passed to Config.OnCallbackError as cause (wrapped)
+var (
+	ErrCallbackPanic   = errors.New("errorid: OnError callback panicked")
+	ErrCallbackTimeout = errors.New("errorid: OnError callback timed out")
+	ErrCallbackDropped = errors.New("errorid: OnError callback dropped")
+)
+
+// Stats are counters of a handler's activity since it was created
+type Stats struct {
+	Wrapped          uint64 // Errors wrapped
+	CallbackPanics   uint64 // OnError calls that panicked
+	CallbackTimeouts uint64 // OnError calls that exceeded CallbackTimeout
+	CallbackDropped  uint64 // Async OnError calls dropped (MaxPendingCallbacks reached)
+	CallbackShed     uint64 // Queued async OnError calls shed for more severe ones (CallbackQueueSize)
+	IDFallbacks      uint64 // IDs generated by IDFallback because crypto/rand failed
+	FloodSuppressed  uint64 // Errors answered with a client's earlier error (FloodThreshold)
+	StoreFailures    uint64 // Config.Store saves that failed
+	IDLogFailures    uint64 // Config.IDLog appends that failed
+	Collapsed        uint64 // Re-wraps merged into an earlier error (DoubleReportGuard)
+	Forwarded        uint64 // Errors received from other processes (ForwardReceiver)
+	StoreDegraded    bool   // Config.Store is a FailoverStore buffering through an outage
+	StoreBuffered    int    // Errors the FailoverStore holds for backfill
+	
+	// Outcomes by context, once OK has been called (nil before)
+	Contexts map[string]ContextRate
+}
+
+// handlerStats holds the live counters behind Stats
+// Shared by handlers derived with WithCallerSkip
+type handlerStats struct {
+	wrapped          atomic.Uint64
+	callbackPanics   atomic.Uint64
+	callbackTimeouts atomic.Uint64
+	callbackDropped  atomic.Uint64
+	callbackShed     atomic.Uint64
+	idFallbacks      atomic.Uint64
+	floodSuppressed  atomic.Uint64
+	storeFailures    atomic.Uint64
+	idLogFailures    atomic.Uint64
+	collapsed        atomic.Uint64
+	forwarded        atomic.Uint64
+	rates            contextRates
+}
+
+// Stats returns a snapshot of the handler's counters
+func (h *Handler) Stats() Stats {
+	stats := Stats{
+		Wrapped:          h.stats.wrapped.Load(),
+		CallbackPanics:   h.stats.callbackPanics.Load(),
+		CallbackTimeouts: h.stats.callbackTimeouts.Load(),
+		CallbackDropped:  h.stats.callbackDropped.Load(),
+		CallbackShed:     h.stats.callbackShed.Load(),
+		IDFallbacks:      h.stats.idFallbacks.Load(),
+		FloodSuppressed:  h.stats.floodSuppressed.Load(),
+		StoreFailures:    h.stats.storeFailures.Load(),
+		IDLogFailures:    h.stats.idLogFailures.Load(),
+		Collapsed:        h.stats.collapsed.Load(),
+		Forwarded:        h.stats.forwarded.Load(),
+		Contexts:         h.stats.rates.snapshot(),
+	}
+	if d, ok := h.config.Store.(degradable); ok {
+		status := d.Status()
+		stats.StoreDegraded, stats.StoreBuffered = status.Degraded, status.Buffered
+	}
+	return stats
+}
@@ -0,0 +1,334 @@
+package errorid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusIncident is what a StatusPageNotifier tells a status page about
+// an error storm. It only holds customer-safe text, never error messages
+type StatusIncident struct {
+	IncidentID  string    // ErrorWithID.IncidentID of the storm
+	Name        string    // Public title
+	Message     string    // Public update text
+	Occurrences int       // Errors of the incident seen so far
+	Started     time.Time // First error of the incident seen
+}
+
+// StatusPageProvider creates and updates incidents on a status page
+// CreateIncident returns the provider's reference for later updates
+type StatusPageProvider interface {
+	CreateIncident(ctx context.Context, incident StatusIncident) (ref string, err error)
+	UpdateIncident(ctx context.Context, ref string, incident StatusIncident) error
+}
+
+// statusSweepSize is the number of tracked incidents above which quiet
+// ones are forgotten
+const statusSweepSize = 256
+
+// StatusPageNotifier opens an incident on a customer-facing status page
+// when an error storm starts (errors get an IncidentID, see
+// Config.IncidentThreshold), and posts progress updates while it lasts.
+// Errors outside incidents are ignored. Resolving stays a human decision
+//
+//	status := &errorid.StatusPageNotifier{
+//		Provider: &errorid.StatuspageProvider{PageID: pageID, APIKey: key},
+//	}
+//	dispatcher := errorid.NewDispatcher(
+//		errorid.Route{Notifier: status, MinSeverity: errorid.SeverityError},
+//	)
+type StatusPageNotifier struct {
+	Provider    StatusPageProvider
+	Title       string        // Incident name, default "Elevated error rates"
+	UpdateEvery time.Duration // Minimum time between updates, default 15 minutes
+	
+	mu        sync.Mutex
+	incidents map[string]*statusIncident
+}
+
+// statusIncident is the provider state of one incident
+type statusIncident struct {
+	mu          sync.Mutex
+	ref         string
+	started     time.Time
+	occurrences int
+	updated     time.Time
+	seen        time.Time
+}
+
+// Notify creates the incident of err on its first error and updates it at
+// most every UpdateEvery. A failed creation is retried with the next error
+func (s *StatusPageNotifier) Notify(ctx context.Context, err *ErrorWithID) error {
+	if err.IncidentID == "" {
+		return nil
+	}
+	now := time.Now()
+	
+	incident := s.incident(err.IncidentID, now)
+	incident.mu.Lock()
+	defer incident.mu.Unlock()
+	
+	incident.occurrences++
+	every := s.UpdateEvery
+	if every <= 0 {
+		every = 15 * time.Minute
+	}
+	
+	status := StatusIncident{
+		IncidentID:  err.IncidentID,
+		Name:        s.Title,
+		Occurrences: incident.occurrences,
+		Started:     incident.started,
+	}
+	if status.Name == "" {
+		status.Name = "Elevated error rates"
+	}
+	
+	if incident.ref == "" {
+		status.Message = "We are investigating elevated error rates. Reference: " + err.IncidentID
+		ref, createErr := s.Provider.CreateIncident(ctx, status)
+		if createErr != nil {
+			return createErr
+		}
+		incident.ref, incident.updated = ref, now
+		return nil
+	}
+	
+	if now.Sub(incident.updated) < every {
+		return nil
+	}
+	status.Message = fmt.Sprintf("We are still investigating elevated error rates (%d errors since %s UTC). Reference: %s",
+		incident.occurrences, incident.started.UTC().Format("15:04"), err.IncidentID)
+	if updateErr := s.Provider.UpdateIncident(ctx, incident.ref, status); updateErr != nil {
+		return updateErr
+	}
+	incident.updated = now
+	return nil
+}
+
+// OnError is a Config.OnError callback notifying err (failures ignored)
+func (s *StatusPageNotifier) OnError(err *ErrorWithID) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	
+	s.Notify(ctx, err)
+}
+
+// incident returns the state of incident id, creating it
+func (s *StatusPageNotifier) incident(id string, now time.Time) *statusIncident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.incidents == nil {
+		s.incidents = make(map[string]*statusIncident)
+	}
+	incident := s.incidents[id]
+	if incident == nil {
+		if len(s.incidents) >= statusSweepSize {
+			for key, old := range s.incidents {
+				if now.Sub(old.seen) > 24*time.Hour {
+					delete(s.incidents, key)
+				}
+			}
+		}
+		incident = &statusIncident{started: now}
+		s.incidents[id] = incident
+	}
+	incident.seen = now
+	return incident
+}
+
+// StatuspageProvider posts incidents to Atlassian Statuspage
+type StatuspageProvider struct {
+	PageID       string
+	APIKey       string
+	ComponentIDs []string     // Components marked as affected
+	BaseURL      string       // Default "https://api.statuspage.io/v1"
+	HTTPClient   *http.Client // Default http.DefaultClient
+}
+
+// CreateIncident implements StatusPageProvider
+func (p *StatuspageProvider) CreateIncident(ctx context.Context, incident StatusIncident) (string, error) {
+	body := map[string]interface{}{"incident": map[string]interface{}{
+		"name":          incident.Name,
+		"status":        "investigating",
+		"body":          incident.Message,
+		"component_ids": p.ComponentIDs,
+	}}
+	var created struct {
+		ID string `json:"id"`
+	}
+	err := requestJSON(ctx, p.HTTPClient, http.MethodPost, p.url("/incidents"), p.header(), body, &created)
+	return created.ID, err
+}
+
+// UpdateIncident implements StatusPageProvider
+func (p *StatuspageProvider) UpdateIncident(ctx context.Context, ref string, incident StatusIncident) error {
+	body := map[string]interface{}{"incident": map[string]interface{}{
+		"status": "investigating",
+		"body":   incident.Message,
+	}}
+	return requestJSON(ctx, p.HTTPClient, http.MethodPatch, p.url("/incidents/"+ref), p.header(), body, nil)
+}
+
+func (p *StatuspageProvider) url(path string) string {
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.statuspage.io/v1"
+	}
+	return strings.TrimSuffix(base, "/") + "/pages/" + p.PageID + path
+}
+
+func (p *StatuspageProvider) header() http.Header {
+	return http.Header{"Authorization": {"OAuth " + p.APIKey}}
+}
+
+// InstatusProvider posts incidents to Instatus
+type InstatusProvider struct {
+	PageID       string
+	APIKey       string
+	ComponentIDs []string     // Components marked as affected
+	BaseURL      string       // Default "https://api.instatus.com/v1"
+	HTTPClient   *http.Client // Default http.DefaultClient
+}
+
+// CreateIncident implements StatusPageProvider
+func (p *InstatusProvider) CreateIncident(ctx context.Context, incident StatusIncident) (string, error) {
+	statuses := make([]map[string]string, len(p.ComponentIDs))
+	for i, id := range p.ComponentIDs {
+		statuses[i] = map[string]string{"id": id, "status": "PARTIALOUTAGE"}
+	}
+	body := map[string]interface{}{
+		"name":       incident.Name,
+		"message":    incident.Message,
+		"components": p.ComponentIDs,
+		"statuses":   statuses,
+		"status":     "INVESTIGATING",
+		"notify":     true,
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	err := requestJSON(ctx, p.HTTPClient, http.MethodPost, p.url("/incidents"), p.header(), body, &created)
+	return created.ID, err
+}
+
+// UpdateIncident implements StatusPageProvider
+func (p *InstatusProvider) UpdateIncident(ctx context.Context, ref string, incident StatusIncident) error {
+	body := map[string]interface{}{
+		"message": incident.Message,
+		"status":  "INVESTIGATING",
+		"notify":  true,
+	}
+	return requestJSON(ctx, p.HTTPClient, http.MethodPost, p.url("/incidents/"+ref+"/incident-updates"), p.header(), body, nil)
+}
+
+func (p *InstatusProvider) url(path string) string {
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.instatus.com/v1"
+	}
+	return strings.TrimSuffix(base, "/") + "/" + p.PageID + path
+}
+
+func (p *InstatusProvider) header() http.Header {
+	return http.Header{"Authorization": {"Bearer " + p.APIKey}}
+}
+
+// CachetProvider posts incidents to a self-hosted Cachet (API v1)
+type CachetProvider struct {
+	BaseURL     string // e.g. "https://status.example.com"
+	Token       string
+	ComponentID int          // Component marked as affected (0 = none)
+	HTTPClient  *http.Client // Default http.DefaultClient
+}
+
+// Cachet incident and component statuses
+const (
+	cachetInvestigating = 1
+	cachetPartialOutage = 3
+)
+
+// CreateIncident implements StatusPageProvider
+func (p *CachetProvider) CreateIncident(ctx context.Context, incident StatusIncident) (string, error) {
+	body := map[string]interface{}{
+		"name":    incident.Name,
+		"message": incident.Message,
+		"status":  cachetInvestigating,
+		"visible": 1,
+	}
+	if p.ComponentID != 0 {
+		body["component_id"] = p.ComponentID
+		body["component_status"] = cachetPartialOutage
+	}
+	var created struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	if err := requestJSON(ctx, p.HTTPClient, http.MethodPost, p.url("/incidents"), p.header(), body, &created); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(created.Data.ID), nil
+}
+
+// UpdateIncident implements StatusPageProvider
+func (p *CachetProvider) UpdateIncident(ctx context.Context, ref string, incident StatusIncident) error {
+	body := map[string]interface{}{
+		"message": incident.Message,
+		"status":  cachetInvestigating,
+	}
+	return requestJSON(ctx, p.HTTPClient, http.MethodPost, p.url("/incidents/"+ref+"/updates"), p.header(), body, nil)
+}
+
+func (p *CachetProvider) url(path string) string {
+	return strings.TrimSuffix(p.BaseURL, "/") + "/api/v1" + path
+}
+
+func (p *CachetProvider) header() http.Header {
+	return http.Header{"X-Cachet-Token": {p.Token}}
+}
+
+// requestJSON sends body as JSON with method and, if out is not nil,
+// decodes the response into it. Non-2xx responses are errors
+func requestJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("errorid: status page got HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,119 @@
+package errorid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrNotFound is returned by Store.Load for unknown IDs
+var ErrNotFound = errors.New("errorid: error ID not found")
+
+// Store persists reported errors so support can look them up by ID
+// (Config.Store, Handler.Lookup). Errors without an ID are not saved
+type Store interface {
+	Save(ctx context.Context, err *ErrorWithID) error
+	Load(ctx context.Context, id string) (*ErrorWithID, error)
+}
+
+// flusher is implemented by stores buffering writes (WriteBehindStore)
+type flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// storeCloser is implemented by stores that stop background work on close
+// (WriteBehindStore, FailoverStore)
+type storeCloser interface {
+	Close(ctx context.Context) error
+}
+
+// closeStore closes store if it is a storeCloser or an io.Closer
+func closeStore(ctx context.Context, store Store) error {
+	switch s := store.(type) {
+	case storeCloser:
+		return s.Close(ctx)
+	case io.Closer:
+		return s.Close()
+	}
+	return nil
+}
+
+// saveError saves a reported error to Config.Store, logging failures
+func (h *Handler) saveError(err *ErrorWithID) {
+	if h.config.Store == nil || err.ID == "" {
+		return
+	}
+	if saveErr := h.config.Store.Save(context.Background(), err); saveErr != nil {
+		h.stats.storeFailures.Add(1)
+		if h.config.Logger != nil {
+			h.config.Logger.Info(fmt.Sprintf("store: saving %s failed: %v", err.ID, saveErr))
+		}
+	}
+}
+
+// Lookup loads the error with id from Config.Store
+// Returns ErrNotFound if there is no store or no such error
+func (h *Handler) Lookup(ctx context.Context, id string) (*ErrorWithID, error) {
+	if h.config.Store == nil {
+		return nil, ErrNotFound
+	}
+	return h.config.Store.Load(ctx, id)
+}
+
+// MemoryStore is a Store keeping the latest errors in memory
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	errors   map[string]*ErrorWithID
+	order    []string // IDs oldest first
+	
+	firstSeen map[string]FirstSeen // by fingerprint, kept after eviction (ReleaseIndex)
+	leases    map[int]nodeLease    // snowflake node IDs (NodeLeaser)
+}
+
+// NewMemoryStore returns a MemoryStore holding up to capacity errors
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &MemoryStore{
+		capacity:  capacity,
+		errors:    make(map[string]*ErrorWithID),
+		firstSeen: make(map[string]FirstSeen),
+	}
+}
+
+// Save implements Store, evicting the oldest error when full
+func (s *MemoryStore) Save(ctx context.Context, err *ErrorWithID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if _, ok := s.errors[err.ID]; !ok {
+		if len(s.order) == s.capacity {
+			delete(s.errors, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.order = append(s.order, err.ID)
+	}
+	s.errors[err.ID] = err
+	
+	if first, ok := firstSeenOf(err); ok {
+		if _, seen := s.firstSeen[first.Fingerprint]; !seen {
+			s.firstSeen[first.Fingerprint] = first
+		}
+	}
+	return nil
+}
+
+// Load implements Store
+func (s *MemoryStore) Load(ctx context.Context, id string) (*ErrorWithID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if err, ok := s.errors[id]; ok {
+		return err, nil
+	}
+	return nil, ErrNotFound
+}
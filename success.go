@@ -0,0 +1,101 @@
+package errorid
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// maxContextRates caps the contexts tracked for error ratios; contexts
+// past it (e.g. built with fmt.Sprintf) are not counted
+const maxContextRates = 1000
+
+// ContextRate counts the outcomes of the operations of one context
+type ContextRate struct {
+	OK         uint64  // Successful completions reported with OK
+	Errors     uint64  // Errors wrapped with the context
+	ErrorRatio float64 // Errors / (OK + Errors)
+}
+
+// contextRates holds the live per-context counters behind Stats.Contexts
+// Nothing is tracked until OK is first called
+type contextRates struct {
+	tracking atomic.Bool
+	count    atomic.Int64
+	counters sync.Map // context -> *contextCounter
+}
+
+type contextCounter struct {
+	ok     atomic.Uint64
+	errors atomic.Uint64
+}
+
+// counter returns the counters of context, creating them below the cap
+func (r *contextRates) counter(context string) *contextCounter {
+	if c, ok := r.counters.Load(context); ok {
+		return c.(*contextCounter)
+	}
+	if r.count.Load() >= maxContextRates {
+		return nil
+	}
+	
+	c, loaded := r.counters.LoadOrStore(context, &contextCounter{})
+	if !loaded {
+		r.count.Add(1)
+	}
+	return c.(*contextCounter)
+}
+
+// errored counts an error wrapped with context
+func (r *contextRates) errored(context string) {
+	if !r.tracking.Load() {
+		return
+	}
+	if c := r.counter(context); c != nil {
+		c.errors.Add(1)
+	}
+}
+
+// snapshot returns the rates by context, nil if OK was never called
+func (r *contextRates) snapshot() map[string]ContextRate {
+	if !r.tracking.Load() {
+		return nil
+	}
+	
+	rates := make(map[string]ContextRate)
+	r.counters.Range(func(key, value interface{}) bool {
+		c := value.(*contextCounter)
+		rate := ContextRate{OK: c.ok.Load(), Errors: c.errors.Load()}
+		if total := rate.OK + rate.Errors; total > 0 {
+			rate.ErrorRatio = float64(rate.Errors) / float64(total)
+		}
+		rates[key.(string)] = rate
+		return true
+	})
+	return rates
+}
+
+// OK counts a successful completion of an operation, under the context it
+// would be wrapped with on failure, so Stats.Contexts can report error
+// ratios rather than raw counts:
+//
+//	order, err := placeOrder(req)
+//	if err != nil {
+//		return h.Wrap(err, "place order")
+//	}
+//	h.OK("place order")
+//
+// Nothing is wrapped, logged or allocated after the first call for a
+// context. Errors are counted by context from the first OK on
+func (h *Handler) OK(context string) {
+	if !h.stats.rates.tracking.Load() {
+		h.stats.rates.tracking.Store(true)
+	}
+	if c := h.stats.rates.counter(context); c != nil {
+		c.ok.Add(1)
+	}
+}
+
+// OK counts a successful completion using the default handler
+func OK(context string) {
+	defaultFor(2).OK(context)
+}
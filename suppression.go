@@ -0,0 +1,237 @@
+package errorid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SuppressionRule mutes matching errors: they keep their ID and are
+// returned to the caller, but are not logged or passed to OnError
+// Every non-empty matcher must match; at least one is required
+type SuppressionRule struct {
+	ID          string    `json:"id"`                    // Assigned on Add
+	Fingerprint string    `json:"fingerprint,omitempty"` // ErrorWithID.Fingerprint()
+	Code        string    `json:"code,omitempty"`        // ErrorCode of the chain
+	Context     string    `json:"context,omitempty"`     // path.Match glob, e.g. "sync *"
+	Reason      string    `json:"reason,omitempty"`
+	Until       time.Time `json:"until,omitempty"` // Expiry, zero = until removed
+	CreatedAt   time.Time `json:"created_at"`
+	Hits        uint64    `json:"hits"` // Errors muted so far
+}
+
+// ErrInvalidRule is returned for rules without matchers or with a bad glob
+var ErrInvalidRule = errors.New("errorid: invalid suppression rule")
+
+// Suppressor holds runtime-managed suppression rules. Use Interceptor in
+// Config.Interceptors and mount Handler on an authenticated admin route,
+// so on-call can mute a noisy error during an incident without a deploy:
+//
+//	mute, err := errorid.NewSuppressor("/var/lib/app/suppressions.json")
+//	errorid.Configure(errorid.Config{Interceptors: []errorid.Interceptor{mute.Interceptor()}})
+//	admin.Handle("/admin/suppressions", mute.Handler())
+type Suppressor struct {
+	mu    sync.Mutex
+	rules []*SuppressionRule
+	file  string // persisted rules, "" for memory only
+}
+
+// NewSuppressor returns a Suppressor persisting its rules to file (JSON)
+// and loading rules already there. An empty file keeps rules in memory
+func NewSuppressor(file string) (*Suppressor, error) {
+	s := &Suppressor{file: file}
+	if file == "" {
+		return s, nil
+	}
+	
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.rules); err != nil {
+		return nil, fmt.Errorf("errorid: reading suppressions %s: %w", file, err)
+	}
+	return s, nil
+}
+
+// Add validates rule, assigns its ID and CreatedAt and persists it
+func (s *Suppressor) Add(rule SuppressionRule) (SuppressionRule, error) {
+	if rule.Fingerprint == "" && rule.Code == "" && rule.Context == "" {
+		return rule, fmt.Errorf("%w: no fingerprint, code or context", ErrInvalidRule)
+	}
+	if _, err := path.Match(rule.Context, ""); err != nil {
+		return rule, fmt.Errorf("%w: context %q: %v", ErrInvalidRule, rule.Context, err)
+	}
+	
+	now := time.Now()
+	rule.ID = "SUP" + strings.TrimPrefix(generateErrorID(now, nil, nil), "ERR")
+	rule.CreatedAt = now
+	rule.Hits = 0
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	s.rules = append(s.rules, &rule)
+	return rule, s.save()
+}
+
+// Remove deletes the rule with id, reporting whether it existed
+func (s *Suppressor) Remove(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for i, rule := range s.rules {
+		if rule.ID == id {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return true, s.save()
+		}
+	}
+	return false, nil
+}
+
+// Rules returns the current rules, expired ones included
+func (s *Suppressor) Rules() []SuppressionRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	rules := make([]SuppressionRule, len(s.rules))
+	for i, rule := range s.rules {
+		rules[i] = *rule
+	}
+	return rules
+}
+
+// save writes the rules to s.file, s.mu must be held
+func (s *Suppressor) save() error {
+	if s.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	
+	// Write-then-rename so a crash never leaves a truncated file
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
+
+// match returns the first active rule matching err, counting the hit
+func (s *Suppressor) match(err *ErrorWithID, now time.Time) *SuppressionRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if len(s.rules) == 0 {
+		return nil
+	}
+	
+	fingerprint, code := "", ""
+	for _, rule := range s.rules {
+		if !rule.Until.IsZero() && now.After(rule.Until) {
+			continue
+		}
+		if rule.Fingerprint != "" {
+			if fingerprint == "" {
+				fingerprint = err.Fingerprint()
+			}
+			if rule.Fingerprint != fingerprint {
+				continue
+			}
+		}
+		if rule.Code != "" {
+			if code == "" {
+				code = ErrorCode(err.Original)
+			}
+			if rule.Code != code {
+				continue
+			}
+		}
+		if rule.Context != "" {
+			if ok, _ := path.Match(rule.Context, err.Context); !ok {
+				continue
+			}
+		}
+		rule.Hits++
+		return rule
+	}
+	return nil
+}
+
+// Interceptor returns an Interceptor that skips reporting of errors
+// matching an active rule
+func (s *Suppressor) Interceptor() Interceptor {
+	return func(next WrapFunc) WrapFunc {
+		return func(err *ErrorWithID) *ErrorWithID {
+			if s.match(err, time.Now()) != nil {
+				return err
+			}
+			return next(err)
+		}
+	}
+}
+
+// Handler serves the rules as a small JSON admin API:
+//
+//	GET    lists rules
+//	POST   adds the SuppressionRule in the body, responds 201 with it
+//	DELETE ?id=SUP-... removes a rule, 204 or 404
+//
+// It does no authentication; mount it behind your admin auth
+func (s *Suppressor) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.Rules())
+		
+		case http.MethodPost:
+			var rule SuppressionRule
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&rule); err != nil {
+				http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			added, err := s.Add(rule)
+			switch {
+			case errors.Is(err, ErrInvalidRule):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			default:
+				writeJSON(w, http.StatusCreated, added)
+			}
+		
+		case http.MethodDelete:
+			removed, err := s.Remove(r.URL.Query().Get("id"))
+			switch {
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			case !removed:
+				http.Error(w, "no such rule", http.StatusNotFound)
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// writeJSON writes v as a JSON response with status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,47 @@
+package errorid
+
+// Tee returns a handler that gives each wrapped error one ID and reports
+// it through every handler: their interceptors, loggers, stores and
+// OnError callbacks all run. Useful while migrating between reporting
+// backends:
+//
+//	handler := errorid.Tee(legacy, replacement)
+//
+// The first handler supplies everything else (ID generation, ErrorFormat,
+// incidents, flood protection, responses) and gets the returned error;
+// the others get copies, so their interceptors can't change what it sees
+// Tee of no handlers returns Default()
+func Tee(handlers ...*Handler) *Handler {
+	if len(handlers) == 0 {
+		return defaultFor(2)
+	}
+	if len(handlers) == 1 {
+		return handlers[0]
+	}
+	
+	tee := *handlers[0]
+	reports := make([]WrapFunc, len(handlers))
+	for i, h := range handlers {
+		reports[i] = h.report
+	}
+	
+	tee.report = func(err *ErrorWithID) *ErrorWithID {
+		for _, report := range reports[1:] {
+			report(copyError(err))
+		}
+		return reports[0](err)
+	}
+	return &tee
+}
+
+// copyError returns a copy of err with its own Details map
+func copyError(err *ErrorWithID) *ErrorWithID {
+	copied := *err
+	if err.Details != nil {
+		copied.Details = make(map[string]interface{}, len(err.Details))
+		for k, v := range err.Details {
+			copied.Details[k] = v
+		}
+	}
+	return &copied
+}
@@ -0,0 +1,106 @@
+package errorid
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// telegramMaxText is the sendMessage text limit
+const telegramMaxText = 4096
+
+// TelegramNotifier sends errors to a Telegram chat through a bot
+//
+//	telegram := &errorid.TelegramNotifier{Token: os.Getenv("BOT_TOKEN"), ChatID: "-1001234567890"}
+//	errorid.Configure(errorid.Config{OnError: telegram.OnError, AsyncCallback: true})
+type TelegramNotifier struct {
+	Token        string       // Bot token from @BotFather
+	ChatID       string       // Chat, group or "@channel" to post to
+	MaxPerMinute int          // Messages per minute, default 20 (< 0 = unlimited)
+	HTTPClient   *http.Client // Default http.DefaultClient
+	APIURL       string       // Default https://api.telegram.org
+	
+	limiter rateLimiter
+}
+
+// telegramMessage is the sendMessage payload
+type telegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+// Notify sends err to the chat
+// Returns ErrRateLimited when MaxPerMinute was reached (never for
+// critical errors)
+func (t *TelegramNotifier) Notify(ctx context.Context, err *ErrorWithID) error {
+	limit := t.MaxPerMinute
+	if limit == 0 {
+		limit = 20
+	}
+	
+	// Critical errors skip the limit without using it up
+	ok, suppressed := true, 0
+	if !isCritical(err) {
+		ok, suppressed = t.limiter.allow(limit, time.Now())
+	}
+	if !ok {
+		return ErrRateLimited
+	}
+	
+	api := t.APIURL
+	if api == "" {
+		api = "https://api.telegram.org"
+	}
+	
+	msg := telegramMessage{ChatID: t.ChatID, Text: t.text(err, suppressed), ParseMode: "HTML"}
+	if len([]rune(msg.Text)) > telegramMaxText {
+		// Cutting HTML could split a tag or entity: send plain text instead
+		msg.Text = truncate(fmt.Sprintf("Error %s: %v", err.ID, err.Original), telegramMaxText)
+		msg.ParseMode = ""
+	}
+	
+	return postJSON(ctx, t.HTTPClient, api+"/bot"+t.Token+"/sendMessage", nil, msg)
+}
+
+// OnError is a Config.OnError callback; delivery errors are dropped
+func (t *TelegramNotifier) OnError(err *ErrorWithID) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	
+	t.Notify(ctx, err)
+}
+
+// text formats err as an HTML message
+func (t *TelegramNotifier) text(err *ErrorWithID, suppressed int) string {
+	var b strings.Builder
+	
+	fmt.Fprintf(&b, "🚨 <b>Error <code>%s</code></b>\n", html.EscapeString(err.ID))
+	if err.Context != "" {
+		fmt.Fprintf(&b, "<b>%s</b>: ", html.EscapeString(err.Context))
+	}
+	fmt.Fprintf(&b, "%s\n", html.EscapeString(fmt.Sprint(err.Original)))
+	if err.Origin != "" {
+		fmt.Fprintf(&b, "<code>%s</code>\n", html.EscapeString(err.Origin))
+	}
+	
+	keys := make([]string, 0, len(err.Details))
+	for k := range err.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	
+	for _, k := range keys {
+		fmt.Fprintf(&b, "• %s: %s\n", html.EscapeString(k), html.EscapeString(fmt.Sprint(err.Details[k])))
+	}
+	
+	if suppressed > 0 {
+		fmt.Fprintf(&b, "<i>%d more error(s) were not sent (rate limit)</i>\n", suppressed)
+	}
+	
+	return b.String()
+}
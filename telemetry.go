@@ -0,0 +1,130 @@
+package errorid
+
+import (
+	"context"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// modulePath is this package's module, for reporting its version
+const modulePath = "github.com/isaui/go-support-id-error"
+
+// TelemetryReport is the body POSTed to Config.TelemetryEndpoint
+// Counts only: no error payloads, IDs or details ever leave the process
+type TelemetryReport struct {
+	Service          string `json:"service"`
+	Host             string `json:"host,omitempty"`
+	PackageVersion   string `json:"package_version,omitempty"`
+	IntervalSeconds  int64  `json:"interval_seconds"`
+	Wrapped          uint64 `json:"wrapped"` // Since the previous report
+	CallbackFailures uint64 `json:"callback_failures"`
+	IDFallbacks      uint64 `json:"id_fallbacks"`
+}
+
+// telemetry periodically reports Stats deltas
+type telemetry struct {
+	h        *Handler
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	
+	last     Stats
+	lastTime time.Time
+}
+
+// startTelemetry starts reporting for h every interval (default 1 minute)
+func startTelemetry(h *Handler, interval time.Duration) *telemetry {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	
+	t := &telemetry{
+		h:        h,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		lastTime: time.Now(),
+	}
+	go t.run()
+	return t
+}
+
+func (t *telemetry) run() {
+	defer close(t.done)
+	
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			t.send(ctx)
+			cancel()
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// close stops the ticker and sends a final report
+func (t *telemetry) close(ctx context.Context) error {
+	t.stopOnce.Do(func() {
+		close(t.stop)
+	})
+	
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.send(ctx)
+}
+
+// send posts the counts since the previous report
+// The periodic loop ignores failures: telemetry must never affect the
+// application
+func (t *telemetry) send(ctx context.Context) error {
+	now, stats := time.Now(), t.h.Stats()
+	report := t.report(stats, now)
+	t.last, t.lastTime = stats, now
+	
+	return postJSON(ctx, nil, t.h.config.TelemetryEndpoint, nil, report)
+}
+
+// report builds the report for the counts between t.last and stats
+func (t *telemetry) report(stats Stats, now time.Time) TelemetryReport {
+	host, _ := os.Hostname()
+	failures := stats.CallbackPanics + stats.CallbackTimeouts + stats.CallbackDropped
+	lastFailures := t.last.CallbackPanics + t.last.CallbackTimeouts + t.last.CallbackDropped
+	
+	return TelemetryReport{
+		Service:          t.h.config.TelemetryService,
+		Host:             host,
+		PackageVersion:   packageVersion(),
+		IntervalSeconds:  int64(now.Sub(t.lastTime).Round(time.Second) / time.Second),
+		Wrapped:          stats.Wrapped - t.last.Wrapped,
+		CallbackFailures: failures - lastFailures,
+		IDFallbacks:      stats.IDFallbacks - t.last.IDFallbacks,
+	}
+}
+
+// packageVersion is the version of this module in the running binary
+func packageVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,311 @@
+package errorid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timelineBuckets is about how many rate buckets a timeline is split into
+const timelineBuckets = 20
+
+// maxTimelineOccurrences caps the occurrences listed in Timeline.Markdown
+const maxTimelineOccurrences = 50
+
+// IncidentIndex is implemented by stores that can list the errors of an
+// incident (MemoryStore, SQLStore), for IncidentTimeline
+type IncidentIndex interface {
+	// ByIncident returns the stored errors with IncidentID id, oldest first
+	ByIncident(ctx context.Context, id string) ([]*ErrorWithID, error)
+}
+
+// Timeline summarizes the occurrences of an error fingerprint or incident
+// for postmortems. It marshals to JSON; Markdown renders it as a document
+type Timeline struct {
+	Fingerprint string              `json:"fingerprint,omitempty"`
+	IncidentID  string              `json:"incident_id,omitempty"`
+	Message     string              `json:"message"` // Of the first occurrence
+	Context     string              `json:"context,omitempty"`
+	Count       int                 `json:"count"`
+	FirstSeen   time.Time           `json:"first_seen"`
+	LastSeen    time.Time           `json:"last_seen"`
+	Events      []TimelineEvent     `json:"events"`
+	Rate        []TimelineBucket    `json:"rate"`
+	Tenants     map[string]int      `json:"tenants,omitempty"`      // Occurrences per tenant (Config.TenantKey)
+	UpstreamIDs map[string][]string `json:"upstream_ids,omitempty"` // Echoed correlation headers (EchoHeaders) and their values
+	RelatedIDs  []string            `json:"related_error_ids,omitempty"`
+	Occurrences []TimelineEntry     `json:"occurrences"`
+}
+
+// TimelineEvent is a notable moment: first/last seen, incident opened,
+// rate rising or falling
+type TimelineEvent struct {
+	Time        time.Time `json:"time"`
+	Description string    `json:"description"`
+}
+
+// TimelineBucket counts occurrences from Start over the bucket width
+type TimelineBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// TimelineEntry is one occurrence
+type TimelineEntry struct {
+	ErrorID string    `json:"error_id"`
+	Time    time.Time `json:"time"`
+	Tenant  string    `json:"tenant,omitempty"`
+	Context string    `json:"context,omitempty"`
+}
+
+// FingerprintTimeline builds the timeline of the stored errors with
+// fingerprint wrapped between since and until. The store must be a
+// FingerprintIndex
+func (h *Handler) FingerprintTimeline(ctx context.Context, fingerprint string, since, until time.Time) (*Timeline, error) {
+	index, ok := h.config.Store.(FingerprintIndex)
+	if !ok {
+		return nil, fmt.Errorf("errorid: store %T can't list errors by fingerprint", h.config.Store)
+	}
+	errs, err := index.ByFingerprint(ctx, fingerprint, since, until)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) == 0 {
+		return nil, ErrNotFound
+	}
+	
+	t := h.buildTimeline(errs)
+	t.Fingerprint = fingerprint
+	return t, nil
+}
+
+// IncidentTimeline builds the timeline of the stored errors of incident
+// id (see IncidentThreshold). The store must be an IncidentIndex
+func (h *Handler) IncidentTimeline(ctx context.Context, id string) (*Timeline, error) {
+	index, ok := h.config.Store.(IncidentIndex)
+	if !ok {
+		return nil, fmt.Errorf("errorid: store %T can't list errors by incident", h.config.Store)
+	}
+	errs, err := index.ByIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) == 0 {
+		return nil, ErrNotFound
+	}
+	
+	t := h.buildTimeline(errs)
+	t.IncidentID = id
+	t.Fingerprint = errs[0].Fingerprint()
+	return t, nil
+}
+
+// buildTimeline summarizes errs, which must not be empty
+func (h *Handler) buildTimeline(errs []*ErrorWithID) *Timeline {
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Timestamp < errs[j].Timestamp })
+	first, last := errs[0], errs[len(errs)-1]
+	
+	t := &Timeline{
+		Message:   errorMessage(first),
+		Context:   first.Context,
+		Count:     len(errs),
+		FirstSeen: time.Unix(first.Timestamp, 0),
+		LastSeen:  time.Unix(last.Timestamp, 0),
+	}
+	
+	key := h.config.TenantKey
+	if key == "" {
+		key = DefaultTenantKey
+	}
+	
+	related := make(map[string]bool)
+	incidents := make(map[string]bool)
+	t.Events = append(t.Events, TimelineEvent{t.FirstSeen, "first seen (" + first.ID + ")"})
+	for _, err := range errs {
+		at := time.Unix(err.Timestamp, 0)
+		tenant := tenantOf(err, key)
+		t.Occurrences = append(t.Occurrences, TimelineEntry{ErrorID: err.ID, Time: at, Tenant: tenant, Context: err.Context})
+	
+		if tenant != "" {
+			if t.Tenants == nil {
+				t.Tenants = make(map[string]int)
+			}
+			t.Tenants[tenant]++
+		}
+		for header, value := range err.Correlation {
+			if t.UpstreamIDs == nil {
+				t.UpstreamIDs = make(map[string][]string)
+			}
+			t.UpstreamIDs[header] = appendUnique(t.UpstreamIDs[header], value)
+		}
+		for _, id := range err.Related {
+			if !related[id] {
+				related[id] = true
+				t.RelatedIDs = append(t.RelatedIDs, id)
+			}
+		}
+		if err.IncidentID != "" && !incidents[err.IncidentID] {
+			incidents[err.IncidentID] = true
+			t.Events = append(t.Events, TimelineEvent{at, "incident " + err.IncidentID + " opened"})
+		}
+	}
+	
+	t.Rate = rateBuckets(errs, t.FirstSeen, t.LastSeen)
+	t.Events = append(t.Events, rateEvents(t.Rate)...)
+	if len(errs) > 1 {
+		t.Events = append(t.Events, TimelineEvent{t.LastSeen, "last seen (" + last.ID + ")"})
+	}
+	sort.SliceStable(t.Events, func(i, j int) bool { return t.Events[i].Time.Before(t.Events[j].Time) })
+	return t
+}
+
+// appendUnique appends value to values unless present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// rateBuckets counts errs (sorted) in about timelineBuckets buckets of at
+// least a minute from first to last
+func rateBuckets(errs []*ErrorWithID, first, last time.Time) []TimelineBucket {
+	width := (last.Sub(first) / timelineBuckets).Round(time.Minute)
+	if width < time.Minute {
+		width = time.Minute
+	}
+	start := first.Truncate(width)
+	
+	buckets := []TimelineBucket{{Start: start}}
+	for _, err := range errs {
+		at := time.Unix(err.Timestamp, 0)
+		for !at.Before(buckets[len(buckets)-1].Start.Add(width)) {
+			buckets = append(buckets, TimelineBucket{Start: buckets[len(buckets)-1].Start.Add(width)})
+		}
+		buckets[len(buckets)-1].Count++
+	}
+	return buckets
+}
+
+// rateEvents reports buckets where the rate at least doubled or halved
+func rateEvents(buckets []TimelineBucket) []TimelineEvent {
+	var events []TimelineEvent
+	for i := 1; i < len(buckets); i++ {
+		prev, cur := buckets[i-1].Count, buckets[i].Count
+		switch {
+		case cur >= 2*max(prev, 1):
+			events = append(events, TimelineEvent{buckets[i].Start, fmt.Sprintf("rate rose from %d to %d per bucket", prev, cur)})
+		case prev > 0 && cur*2 <= prev:
+			events = append(events, TimelineEvent{buckets[i].Start, fmt.Sprintf("rate fell from %d to %d per bucket", prev, cur)})
+		}
+	}
+	return events
+}
+
+// Markdown renders the timeline as a postmortem-ready document
+func (t *Timeline) Markdown() string {
+	var b strings.Builder
+	
+	title := t.IncidentID
+	if title == "" {
+		title = "fingerprint " + t.Fingerprint
+	}
+	fmt.Fprintf(&b, "## Timeline: %s\n\n", title)
+	fmt.Fprintf(&b, "- **Error:** %s\n", t.Message)
+	if t.Context != "" {
+		fmt.Fprintf(&b, "- **Context:** %s\n", t.Context)
+	}
+	fmt.Fprintf(&b, "- **Fingerprint:** `%s`\n", t.Fingerprint)
+	fmt.Fprintf(&b, "- **Occurrences:** %d\n", t.Count)
+	fmt.Fprintf(&b, "- **First seen:** %s\n", t.FirstSeen.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "- **Last seen:** %s\n", t.LastSeen.UTC().Format(time.RFC3339))
+	if len(t.Tenants) > 0 {
+		tenants := mapKeys(t.Tenants)
+		for i, tenant := range tenants {
+			tenants[i] = fmt.Sprintf("%s (%d)", tenant, t.Tenants[tenant])
+		}
+		fmt.Fprintf(&b, "- **Affected tenants:** %s\n", strings.Join(tenants, ", "))
+	}
+	for _, header := range mapKeys(t.UpstreamIDs) {
+		fmt.Fprintf(&b, "- **%s:** %s\n", header, strings.Join(t.UpstreamIDs[header], ", "))
+	}
+	if len(t.RelatedIDs) > 0 {
+		fmt.Fprintf(&b, "- **Related errors:** %s\n", strings.Join(t.RelatedIDs, ", "))
+	}
+	
+	b.WriteString("\n### Events\n\n| Time (UTC) | Event |\n|---|---|\n")
+	for _, e := range t.Events {
+		fmt.Fprintf(&b, "| %s | %s |\n", e.Time.UTC().Format(time.RFC3339), e.Description)
+	}
+	
+	b.WriteString("\n### Rate\n\n| From (UTC) | Occurrences |\n|---|---|\n")
+	for _, bucket := range t.Rate {
+		fmt.Fprintf(&b, "| %s | %d |\n", bucket.Start.UTC().Format(time.RFC3339), bucket.Count)
+	}
+	
+	b.WriteString("\n### Occurrences\n\n| Time (UTC) | Error ID | Tenant |\n|---|---|---|\n")
+	for i, o := range t.Occurrences {
+		if i == maxTimelineOccurrences {
+			fmt.Fprintf(&b, "\n_%d more not shown_\n", len(t.Occurrences)-i)
+			break
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", o.Time.UTC().Format(time.RFC3339), o.ErrorID, o.Tenant)
+	}
+	return b.String()
+}
+
+// ByIncident implements IncidentIndex for the errors still held
+func (s *MemoryStore) ByIncident(ctx context.Context, id string) ([]*ErrorWithID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var matches []*ErrorWithID
+	for _, errID := range s.order {
+		if err := s.errors[errID]; err.IncidentID == id {
+			matches = append(matches, err)
+		}
+	}
+	return matches, nil
+}
+
+// ByIncident implements IncidentIndex by scanning the errors stored around
+// the date in the incident ID (INC-YYYYMMDD-...)
+func (s *SQLStore) ByIncident(ctx context.Context, id string) ([]*ErrorWithID, error) {
+	parts := strings.Split(id, "-")
+	if len(parts) < 3 {
+		return nil, ErrNotFound
+	}
+	day, err := time.ParseInLocation("20060102", parts[1], time.Local)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	
+	// Incidents may span midnight and IDs may be dated in another zone
+	rows, err := s.DB.QueryContext(ctx, s.query("SELECT data FROM %s WHERE timestamp >= ? AND timestamp <= ? ORDER BY timestamp"),
+		day.AddDate(0, 0, -1).Unix(), day.AddDate(0, 0, 2).Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var matches []*ErrorWithID
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var record bundleError
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			continue
+		}
+		if record.IncidentID == id {
+			matches = append(matches, record.errorWithID())
+		}
+	}
+	return matches, rows.Err()
+}
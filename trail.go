@@ -0,0 +1,52 @@
+package errorid
+
+import (
+	"errors"
+	"time"
+)
+
+// TrailEntry is one layer that wrapped an error on its way up the stack
+type TrailEntry struct {
+	Context string    `json:"context"`
+	ErrorID string    `json:"error_id,omitempty"` // ID the layer returned
+	Time    time.Time `json:"time"`
+	Origin  string    `json:"origin,omitempty"` // Wrap site as "file:line function"
+}
+
+// String formats the entry as "15:04:05.000 context (origin)"
+func (e TrailEntry) String() string {
+	s := e.Time.Format("15:04:05.000") + " " + e.Context
+	if e.Origin != "" {
+		s += " (" + e.Origin + ")"
+	}
+	return s
+}
+
+// trailEntry records the current wrap in context, or nil if RecordTrail
+// is off
+func (h *Handler) trailEntry(context string) *TrailEntry {
+	if !h.config.RecordTrail {
+		return nil
+	}
+	return &TrailEntry{
+		Context: context,
+		Time:    time.Now(),
+		Origin:  captureOrigin(h.callerSkip),
+	}
+}
+
+// trailOf returns the trail of a new error wrapping err: that of the
+// nearest ErrorWithID in the chain, if any, followed by entry
+func trailOf(err error, entry *TrailEntry) []TrailEntry {
+	if entry == nil {
+		return nil
+	}
+	
+	var inner *ErrorWithID
+	if !errors.As(err, &inner) {
+		return []TrailEntry{*entry}
+	}
+	trail := make([]TrailEntry, len(inner.Trail), len(inner.Trail)+1)
+	copy(trail, inner.Trail)
+	return append(trail, *entry)
+}
@@ -0,0 +1,90 @@
+package errorid
+
+import (
+	"errors"
+	"regexp"
+)
+
+// Translation maps upstream errors to an internal Definition. Every
+// non-zero matcher must match; a Translation without matchers never does
+type Translation struct {
+	Status  int            // Upstream HTTP status (StatusCoder or HTTPStatusCode() int)
+	Code    string         // Upstream error code (Coder, e.g. AWS ErrorCode())
+	Message *regexp.Regexp // Matched against the error message
+	
+	To *Definition // Internal code, status, public message and classification
+}
+
+// matches reports whether err falls under t
+func (t Translation) matches(err error) bool {
+	if t.Status == 0 && t.Code == "" && t.Message == nil {
+		return false
+	}
+	if t.Status != 0 && upstreamStatus(err) != t.Status {
+		return false
+	}
+	if t.Code != "" && ErrorCode(err) != t.Code {
+		return false
+	}
+	if t.Message != nil && !t.Message.MatchString(err.Error()) {
+		return false
+	}
+	return true
+}
+
+// upstreamStatus returns the HTTP status carried by err's chain: a
+// StatusCoder, or an SDK error with HTTPStatusCode() int
+func upstreamStatus(err error) int {
+	if status := HTTPStatus(err); status != 0 {
+		return status
+	}
+	var sdk interface{ HTTPStatusCode() int }
+	if errors.As(err, &sdk) {
+		return sdk.HTTPStatusCode()
+	}
+	return 0
+}
+
+// Translator rewrites upstream vendor errors (AWS, Stripe, ...) into
+// internal codes, categories and public messages, in one place instead of
+// at every call site. Set it as Config.Translator to apply it on Wrap:
+//
+//	errorid.NewTranslator(
+//	    errorid.Translation{Code: "ThrottlingException", To: ErrBusy},
+//	    errorid.Translation{Status: 402, Message: regexp.MustCompile(`card_declined`), To: ErrCardDeclined},
+//	)
+type Translator struct {
+	translations []Translation
+}
+
+// NewTranslator returns a Translator trying translations in order
+func NewTranslator(translations ...Translation) *Translator {
+	return &Translator{translations: translations}
+}
+
+// Translate returns err carrying the Definition of the first matching
+// translation, or err unchanged. The result unwraps to both, so
+// errors.Is/As still find the upstream error and errors.Is(result, def)
+// holds, while code, status and public message come from the Definition
+func (t *Translator) Translate(err error) error {
+	if t == nil || err == nil {
+		return err
+	}
+	for _, translation := range t.translations {
+		if translation.To != nil && translation.matches(err) {
+			return &translatedError{def: translation.To, err: err}
+		}
+	}
+	return err
+}
+
+// translatedError is an upstream error with its internal Definition
+type translatedError struct {
+	def *Definition
+	err error
+}
+
+func (e *translatedError) Error() string { return e.err.Error() }
+
+// Unwrap lists the Definition first so its code, status and message win
+func (e *translatedError) Unwrap() []error { return []error{e.def, e.err} }
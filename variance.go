@@ -0,0 +1,172 @@
+package errorid
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// maxVarianceValues caps the distinct values remembered per detail key;
+// past it, Distinct is a lower bound
+const maxVarianceValues = 32
+
+// KeyVariance describes how one Details key varies across occurrences of
+// an error: constant keys point at the cause (same endpoint), varying ones
+// at the blast radius (different user_ids)
+type KeyVariance struct {
+	Key      string
+	Present  int      // Occurrences that had the key
+	Distinct int      // Distinct values seen (at least, if Capped)
+	Values   []string // Up to 32 distinct values, in first-seen order
+	Capped   bool     // More distinct values than Values holds
+}
+
+// Constant reports whether every occurrence with the key had the same value
+func (v KeyVariance) Constant() bool {
+	return v.Distinct == 1
+}
+
+// detailVariance accumulates KeyVariance for a set of occurrences
+type detailVariance struct {
+	occurrences int
+	keys        map[string]*keyValues
+}
+
+type keyValues struct {
+	present int
+	values  []string
+	seen    map[string]bool
+	capped  bool
+}
+
+func (d *detailVariance) add(details map[string]interface{}) {
+	d.occurrences++
+	if d.keys == nil {
+		d.keys = make(map[string]*keyValues)
+	}
+	for key, value := range details {
+		kv := d.keys[key]
+		if kv == nil {
+			kv = &keyValues{seen: make(map[string]bool)}
+			d.keys[key] = kv
+		}
+		kv.present++
+		
+		text := fmt.Sprint(value)
+		switch {
+		case kv.seen[text]:
+		case len(kv.values) == maxVarianceValues:
+			kv.capped = true
+		default:
+			kv.seen[text] = true
+			kv.values = append(kv.values, text)
+		}
+	}
+}
+
+// result returns the variance of every key, sorted by key
+func (d *detailVariance) result() []KeyVariance {
+	result := make([]KeyVariance, 0, len(d.keys))
+	for key, kv := range d.keys {
+		distinct := len(kv.values)
+		if kv.capped {
+			distinct++
+		}
+		result = append(result, KeyVariance{
+			Key:      key,
+			Present:  kv.present,
+			Distinct: distinct,
+			Values:   append([]string(nil), kv.values...),
+			Capped:   kv.capped,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// CompareDetails returns how Details keys vary across errs, e.g. the
+// occurrences of one fingerprint found with SearchIndex
+func CompareDetails(errs []*ErrorWithID) []KeyVariance {
+	var d detailVariance
+	for _, err := range errs {
+		d.add(err.Details)
+	}
+	return d.result()
+}
+
+// VarianceTracker accumulates detail variance per Fingerprint as errors
+// are reported, so recurring errors can be summarized without keeping
+// every occurrence
+//
+//	tracker := errorid.NewVarianceTracker(1000)
+//	errorid.Configure(errorid.Config{OnError: tracker.OnError})
+//	for _, v := range tracker.Variance(err.Fingerprint()) { ... }
+type VarianceTracker struct {
+	mu           sync.Mutex
+	maxPrints    int
+	fingerprints map[string]*detailVariance
+	order        []string // fingerprints by first occurrence, for eviction
+}
+
+// NewVarianceTracker tracks up to maxFingerprints fingerprints, forgetting
+// the oldest ones past that
+func NewVarianceTracker(maxFingerprints int) *VarianceTracker {
+	if maxFingerprints <= 0 {
+		maxFingerprints = 1
+	}
+	return &VarianceTracker{
+		maxPrints:    maxFingerprints,
+		fingerprints: make(map[string]*detailVariance),
+	}
+}
+
+// OnError is a Config.OnError callback recording err
+func (t *VarianceTracker) OnError(err *ErrorWithID) {
+	t.Add(err)
+}
+
+// Add records the details of err under its fingerprint
+func (t *VarianceTracker) Add(err *ErrorWithID) {
+	if err == nil {
+		return
+	}
+	fingerprint := err.Fingerprint()
+	
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	d := t.fingerprints[fingerprint]
+	if d == nil {
+		if len(t.order) == t.maxPrints {
+			delete(t.fingerprints, t.order[0])
+			t.order = t.order[1:]
+		}
+		d = &detailVariance{}
+		t.fingerprints[fingerprint] = d
+		t.order = append(t.order, fingerprint)
+	}
+	d.add(err.Details)
+}
+
+// Occurrences returns how many errors were recorded for fingerprint
+func (t *VarianceTracker) Occurrences(fingerprint string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	if d := t.fingerprints[fingerprint]; d != nil {
+		return d.occurrences
+	}
+	return 0
+}
+
+// Variance returns the detail variance of fingerprint, nil if unknown
+func (t *VarianceTracker) Variance(fingerprint string) []KeyVariance {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	d := t.fingerprints[fingerprint]
+	if d == nil {
+		return nil
+	}
+	return d.result()
+}
@@ -0,0 +1,159 @@
+package errorid
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrStoreClosed is returned by WriteBehindStore.Save after Close
+var ErrStoreClosed = errors.New("errorid: store closed")
+
+// WriteBehindStore makes a slow Store asynchronous: Save returns at once
+// and a background goroutine writes to the durable store, while Load
+// serves pending errors from memory so very recent IDs are found before
+// their write completes. Wrap latency stays flat however slow the store
+// (with SpoolDir, Save also waits for the spool file to be synced)
+//
+//	store := errorid.NewWriteBehindStore(sqlStore, 1024)
+//	errorid.Configure(errorid.Config{Store: store})
+//	defer errorid.Default().Close(ctx) // also closes store
+//
+// When the queue is full, Save writes synchronously rather than drop
+// Write failures go to OnError, if set
+type WriteBehindStore struct {
+	store   Store
+	queue   chan *ErrorWithID
+	mu      sync.Mutex
+	pending map[string]*ErrorWithID
+	writing sync.WaitGroup // queued and in-progress writes
+	closed  bool
+	done    chan struct{}
+	
+	closeOnce sync.Once
+	closeErr  error
+	
+	// OnError is called with errors from background writes
+	OnError func(err *ErrorWithID, cause error)
+	
+	// SpoolDir, if set, keeps a file per queued error until it is written,
+	// so errors queued when the process dies are written by Replay on the
+	// next start. Save writes and fsyncs the file before returning: budget
+	// for an fsync per error on slow disks. Set it before the first Save
+	SpoolDir string
+}
+
+// NewWriteBehindStore returns a WriteBehindStore queueing up to size
+// writes to store
+func NewWriteBehindStore(store Store, size int) *WriteBehindStore {
+	if size <= 0 {
+		size = 1
+	}
+	s := &WriteBehindStore{
+		store:   store,
+		queue:   make(chan *ErrorWithID, size),
+		pending: make(map[string]*ErrorWithID),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// run writes queued errors to the durable store
+func (s *WriteBehindStore) run() {
+	defer close(s.done)
+	for err := range s.queue {
+		if saveErr := s.store.Save(context.Background(), err); saveErr != nil {
+			if s.OnError != nil {
+				s.OnError(err, saveErr)
+			}
+		} else {
+			s.unspool(err)
+		}
+		
+		s.mu.Lock()
+		if s.pending[err.ID] == err {
+			delete(s.pending, err.ID)
+		}
+		s.mu.Unlock()
+		s.writing.Done()
+	}
+}
+
+// Save implements Store: err is queued and immediately visible to Load
+// With SpoolDir it is also spooled to disk until written
+func (s *WriteBehindStore) Save(ctx context.Context, err *ErrorWithID) error {
+	// Spool before queueing so the writer can't finish first
+	s.spool(err)
+	
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		s.unspool(err)
+		return ErrStoreClosed
+	}
+	
+	select {
+	case s.queue <- err:
+		s.pending[err.ID] = err
+		s.writing.Add(1)
+		s.mu.Unlock()
+		return nil
+	default:
+		s.mu.Unlock()
+		saveErr := s.store.Save(ctx, err)
+		if saveErr == nil {
+			s.unspool(err)
+		}
+		return saveErr
+	}
+}
+
+// Load implements Store, checking pending writes first
+func (s *WriteBehindStore) Load(ctx context.Context, id string) (*ErrorWithID, error) {
+	s.mu.Lock()
+	err, ok := s.pending[id]
+	s.mu.Unlock()
+	if ok {
+		return err, nil
+	}
+	return s.store.Load(ctx, id)
+}
+
+// Flush waits until queued writes have completed, or ctx ends
+func (s *WriteBehindStore) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.writing.Wait()
+		close(done)
+	}()
+	
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting writes, waits for queued ones and then closes the
+// durable store if it has a Close method, or returns when ctx ends
+func (s *WriteBehindStore) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	
+	s.closeOnce.Do(func() {
+		s.closeErr = closeStore(ctx, s.store)
+	})
+	return s.closeErr
+}